@@ -1,664 +1,8987 @@
-package main
-
-import (
-	"bufio"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"os"
-	"strconv"
-	"strings"
-)
-
-// User 定义了用户结构体，Role 字段为 "admin" 或 "customer"。
-// 对于顾客，CustomerType 表示会员或普通账号，Balance 表示账户余额。
-type User struct {
-	ID           int     `json:"id"`
-	Username     string  `json:"username"`
-	Password     string  `json:"password"`
-	Role         string  `json:"role"`          // "admin" 或 "customer"
-	CustomerType string  `json:"customer_type"` // "member" 或 "regular"，仅当 Role 为 "customer" 时有效
-	Balance      float64 `json:"balance"`       // 仅当 Role 为 "customer" 时有效
-}
-
-// Room 定义了酒店房间结构体，Available 表示当前剩余可预订数量。
-type Room struct {
-	ID        int     `json:"id"`
-	Type      string  `json:"type"`      // 房间类型，如单人间、双人间等
-	Price     float64 `json:"price"`     // 房间价格
-	Total     int     `json:"total"`     // 房间总数量
-	Available int     `json:"available"` // 当前剩余数量
-}
-
-var users []User
-var rooms []Room
-
-const usersFile = "users.json"
-const roomsFile = "rooms.json"
-
-var reader = bufio.NewReader(os.Stdin)
-
-func main() {
-	// 加载用户和房间数据
-	loadUsers()
-	loadRooms()
-
-	for {
-		fmt.Println("================================")
-		fmt.Println("欢迎使用酒店管理系统")
-		fmt.Println("1. 登录")
-		fmt.Println("2. 注册（仅限顾客）")
-		fmt.Println("3. 退出")
-		fmt.Print("请选择操作：")
-		choice := readLine()
-		switch choice {
-		case "1":
-			user := login()
-			if user != nil {
-				if user.Role == "admin" {
-					adminMenu(user)
-				} else if user.Role == "customer" {
-					customerMenu(user)
-				}
-			}
-		case "2":
-			registerCustomer()
-		case "3":
-			fmt.Println("退出系统")
-			return
-		default:
-			fmt.Println("无效的选项，请重试。")
-		}
-	}
-}
-
-// readLine 从标准输入读取一行数据并去掉末尾换行符
-func readLine() string {
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
-}
-
-// ------------------------- 数据持久化相关 ----------------------------
-
-// 加载用户数据，如果文件不存在则初始化默认管理员账号
-func loadUsers() {
-	data, err := ioutil.ReadFile(usersFile)
-	if err != nil {
-		// 文件不存在，初始化默认管理员账号
-		fmt.Println("未找到用户数据文件，初始化默认管理员账号。")
-		users = []User{
-			{
-				ID:       1,
-				Username: "admin",
-				Password: "admin",
-				Role:     "admin",
-			},
-		}
-		saveUsers()
-		return
-	}
-	err = json.Unmarshal(data, &users)
-	if err != nil {
-		fmt.Println("加载用户数据错误：", err)
-		os.Exit(1)
-	}
-}
-
-// 保存用户数据到文件
-func saveUsers() {
-	data, err := json.MarshalIndent(users, "", "  ")
-	if err != nil {
-		fmt.Println("保存用户数据错误：", err)
-		return
-	}
-	err = ioutil.WriteFile(usersFile, data, 0644)
-	if err != nil {
-		fmt.Println("写入用户数据文件错误：", err)
-	}
-}
-
-// 加载房间数据，如果文件不存在则初始化为空房间列表
-func loadRooms() {
-	data, err := ioutil.ReadFile(roomsFile)
-	if err != nil {
-		fmt.Println("未找到房间数据文件，初始化空房间列表。")
-		rooms = []Room{}
-		saveRooms()
-		return
-	}
-	err = json.Unmarshal(data, &rooms)
-	if err != nil {
-		fmt.Println("加载房间数据错误：", err)
-		os.Exit(1)
-	}
-}
-
-// 保存房间数据到文件
-func saveRooms() {
-	data, err := json.MarshalIndent(rooms, "", "  ")
-	if err != nil {
-		fmt.Println("保存房间数据错误：", err)
-		return
-	}
-	err = ioutil.WriteFile(roomsFile, data, 0644)
-	if err != nil {
-		fmt.Println("写入房间数据文件错误：", err)
-	}
-}
-
-// ------------------------- 登录与注册 ----------------------------
-
-// login 实现用户登录，输入用户名和密码后返回对应的用户指针（成功则返回，不成功返回 nil）
-func login() *User {
-	fmt.Print("请输入用户名：")
-	username := readLine()
-	fmt.Print("请输入密码：")
-	password := readLine()
-
-	for i := range users {
-		if users[i].Username == username && users[i].Password == password {
-			fmt.Println("登录成功！")
-			return &users[i]
-		}
-	}
-	fmt.Println("用户名或密码错误！")
-	return nil
-}
-
-// registerCustomer 仅允许注册顾客账号（会员或普通），默认初始余额 1000 元
-func registerCustomer() {
-	fmt.Println("注册新顾客账号")
-	fmt.Print("请输入用户名：")
-	username := readLine()
-	// 检查用户名是否已存在
-	for _, user := range users {
-		if user.Username == username {
-			fmt.Println("用户名已存在！")
-			return
-		}
-	}
-	fmt.Print("请输入密码：")
-	password := readLine()
-	fmt.Print("请选择顾客类型（1. 会员账号 2. 普通账号）：")
-	choice := readLine()
-	var customerType string
-	if choice == "1" {
-		customerType = "member"
-	} else {
-		customerType = "regular"
-	}
-	newUser := User{
-		ID:           getNextUserID(),
-		Username:     username,
-		Password:     password,
-		Role:         "customer",
-		CustomerType: customerType,
-		Balance:      1000.0,
-	}
-	users = append(users, newUser)
-	saveUsers()
-	fmt.Println("注册成功！初始余额为 1000 元。")
-}
-
-// getNextUserID 获取下一个用户 ID（自动递增）
-func getNextUserID() int {
-	maxID := 0
-	for _, user := range users {
-		if user.ID > maxID {
-			maxID = user.ID
-		}
-	}
-	return maxID + 1
-}
-
-// getNextRoomID 获取下一个房间 ID（自动递增）
-func getNextRoomID() int {
-	maxID := 0
-	for _, room := range rooms {
-		if room.ID > maxID {
-			maxID = room.ID
-		}
-	}
-	return maxID + 1
-}
-
-// ------------------------- 管理员功能 ----------------------------
-
-// adminMenu 为管理员提供用户管理和房间管理的菜单
-func adminMenu(user *User) {
-	for {
-		fmt.Println("================================")
-		fmt.Println("管理员菜单")
-		fmt.Println("1. 用户管理")
-		fmt.Println("2. 房间管理")
-		fmt.Println("3. 退出")
-		fmt.Print("请选择操作：")
-		choice := readLine()
-		switch choice {
-		case "1":
-			adminUserManagement()
-		case "2":
-			adminRoomManagement()
-		case "3":
-			fmt.Println("注销成功")
-			return
-		default:
-			fmt.Println("无效的选项，请重试。")
-		}
-	}
-}
-
-// adminUserManagement 实现管理员对用户的增删改查操作
-func adminUserManagement() {
-	for {
-		fmt.Println("--------- 用户管理 ---------")
-		fmt.Println("1. 查看所有用户")
-		fmt.Println("2. 添加用户")
-		fmt.Println("3. 修改用户")
-		fmt.Println("4. 删除用户")
-		fmt.Println("5. 返回上一层")
-		fmt.Print("请选择操作：")
-		choice := readLine()
-		switch choice {
-		case "1":
-			listUsers()
-		case "2":
-			addUser()
-		case "3":
-			updateUser()
-		case "4":
-			deleteUser()
-		case "5":
-			return
-		default:
-			fmt.Println("无效的选项，请重试。")
-		}
-	}
-}
-
-// listUsers 显示所有用户信息
-func listUsers() {
-	fmt.Println("----- 所有用户列表 -----")
-	for _, user := range users {
-		fmt.Printf("ID: %d, 用户名: %s, 角色: %s", user.ID, user.Username, user.Role)
-		if user.Role == "customer" {
-			fmt.Printf(", 类型: %s, 余额: %.2f", user.CustomerType, user.Balance)
-		}
-		fmt.Println()
-	}
-}
-
-// addUser 由管理员添加新用户，可以添加管理员或顾客账号
-func addUser() {
-	fmt.Println("----- 添加新用户 -----")
-	fmt.Print("请输入用户名：")
-	username := readLine()
-	// 检查用户名是否已存在
-	for _, user := range users {
-		if user.Username == username {
-			fmt.Println("用户名已存在！")
-			return
-		}
-	}
-	fmt.Print("请输入密码：")
-	password := readLine()
-	fmt.Print("请选择角色（1. 管理员 2. 顾客）：")
-	roleChoice := readLine()
-	var role string
-	var customerType string
-	var balance float64
-	if roleChoice == "1" {
-		role = "admin"
-	} else if roleChoice == "2" {
-		role = "customer"
-		fmt.Print("请选择顾客类型（1. 会员账号 2. 普通账号）：")
-		ctChoice := readLine()
-		if ctChoice == "1" {
-			customerType = "member"
-		} else {
-			customerType = "regular"
-		}
-		balance = 1000.0 // 初始余额
-	} else {
-		fmt.Println("无效的角色选项")
-		return
-	}
-	newUser := User{
-		ID:           getNextUserID(),
-		Username:     username,
-		Password:     password,
-		Role:         role,
-		CustomerType: customerType,
-		Balance:      balance,
-	}
-	users = append(users, newUser)
-	saveUsers()
-	fmt.Println("用户添加成功！")
-}
-
-// updateUser 修改指定用户的信息
-func updateUser() {
-	fmt.Print("请输入要修改的用户ID：")
-	idStr := readLine()
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Println("无效的ID")
-		return
-	}
-	var user *User
-	for i := range users {
-		if users[i].ID == id {
-			user = &users[i]
-			break
-		}
-	}
-	if user == nil {
-		fmt.Println("未找到该用户")
-		return
-	}
-	fmt.Printf("当前用户名: %s\n", user.Username)
-	fmt.Print("请输入新的用户名（直接回车保持不变）：")
-	newUsername := readLine()
-	if newUsername != "" {
-		user.Username = newUsername
-	}
-	fmt.Print("请输入新的密码（直接回车保持不变）：")
-	newPassword := readLine()
-	if newPassword != "" {
-		user.Password = newPassword
-	}
-	// 如果是顾客，则可修改顾客类型和余额
-	if user.Role == "customer" {
-		fmt.Printf("当前顾客类型: %s\n", user.CustomerType)
-		fmt.Print("请选择新的顾客类型（1. 会员账号 2. 普通账号，回车保持不变）：")
-		ctChoice := readLine()
-		if ctChoice == "1" {
-			user.CustomerType = "member"
-		} else if ctChoice == "2" {
-			user.CustomerType = "regular"
-		}
-		fmt.Printf("当前余额: %.2f\n", user.Balance)
-		fmt.Print("请输入新的余额（回车保持不变）：")
-		balanceStr := readLine()
-		if balanceStr != "" {
-			b, err := strconv.ParseFloat(balanceStr, 64)
-			if err == nil {
-				user.Balance = b
-			} else {
-				fmt.Println("无效的余额输入")
-			}
-		}
-	}
-	saveUsers()
-	fmt.Println("用户信息更新成功")
-}
-
-// deleteUser 删除指定用户（管理员操作）
-func deleteUser() {
-	fmt.Print("请输入要删除的用户ID：")
-	idStr := readLine()
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Println("无效的ID")
-		return
-	}
-	index := -1
-	for i, user := range users {
-		if user.ID == id {
-			index = i
-			break
-		}
-	}
-	if index == -1 {
-		fmt.Println("未找到该用户")
-		return
-	}
-	fmt.Print("确定要删除该用户吗？(y/n): ")
-	confirm := readLine()
-	if confirm != "y" && confirm != "Y" {
-		return
-	}
-	users = append(users[:index], users[index+1:]...)
-	saveUsers()
-	fmt.Println("用户删除成功")
-}
-
-// adminRoomManagement 管理员对房间的增删改查操作
-func adminRoomManagement() {
-	for {
-		fmt.Println("--------- 房间管理 ---------")
-		fmt.Println("1. 查看所有房间")
-		fmt.Println("2. 添加房间")
-		fmt.Println("3. 修改房间")
-		fmt.Println("4. 删除房间")
-		fmt.Println("5. 返回上一层")
-		fmt.Print("请选择操作：")
-		choice := readLine()
-		switch choice {
-		case "1":
-			listRooms()
-		case "2":
-			addRoom()
-		case "3":
-			updateRoom()
-		case "4":
-			deleteRoom()
-		case "5":
-			return
-		default:
-			fmt.Println("无效的选项，请重试。")
-		}
-	}
-}
-
-// listRooms 显示所有房间信息
-func listRooms() {
-	if len(rooms) == 0 {
-		fmt.Println("当前无房间信息")
-		return
-	}
-	fmt.Println("----- 房间列表 -----")
-	for _, room := range rooms {
-		fmt.Printf("ID: %d, 类型: %s, 价格: %.2f, 总数: %d, 剩余: %d\n",
-			room.ID, room.Type, room.Price, room.Total, room.Available)
-	}
-}
-
-// addRoom 添加新房间（仅管理员操作）
-func addRoom() {
-	fmt.Println("----- 添加新房间 -----")
-	fmt.Print("请输入房间类型：")
-	roomType := readLine()
-	fmt.Print("请输入房间价格：")
-	priceStr := readLine()
-	price, err := strconv.ParseFloat(priceStr, 64)
-	if err != nil {
-		fmt.Println("无效的价格输入")
-		return
-	}
-	fmt.Print("请输入房间总数：")
-	totalStr := readLine()
-	total, err := strconv.Atoi(totalStr)
-	if err != nil {
-		fmt.Println("无效的房间数量")
-		return
-	}
-	newRoom := Room{
-		ID:        getNextRoomID(),
-		Type:      roomType,
-		Price:     price,
-		Total:     total,
-		Available: total,
-	}
-	rooms = append(rooms, newRoom)
-	saveRooms()
-	fmt.Println("房间添加成功！")
-}
-
-// updateRoom 修改房间信息
-func updateRoom() {
-	fmt.Print("请输入要修改的房间ID：")
-	idStr := readLine()
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Println("无效的ID")
-		return
-	}
-	var room *Room
-	for i := range rooms {
-		if rooms[i].ID == id {
-			room = &rooms[i]
-			break
-		}
-	}
-	if room == nil {
-		fmt.Println("未找到该房间")
-		return
-	}
-	fmt.Printf("当前房间类型: %s\n", room.Type)
-	fmt.Print("请输入新的房间类型（回车保持不变）：")
-	newType := readLine()
-	if newType != "" {
-		room.Type = newType
-	}
-	fmt.Printf("当前价格: %.2f\n", room.Price)
-	fmt.Print("请输入新的价格（回车保持不变）：")
-	priceStr := readLine()
-	if priceStr != "" {
-		price, err := strconv.ParseFloat(priceStr, 64)
-		if err == nil {
-			room.Price = price
-		} else {
-			fmt.Println("无效的价格输入")
-		}
-	}
-	fmt.Printf("当前总数: %d\n", room.Total)
-	fmt.Print("请输入新的总数（回车保持不变）：")
-	totalStr := readLine()
-	if totalStr != "" {
-		total, err := strconv.Atoi(totalStr)
-		if err == nil {
-			// 调整剩余数量（假设已有预订时不允许负数）
-			diff := total - room.Total
-			room.Total = total
-			room.Available += diff
-			if room.Available < 0 {
-				room.Available = 0
-			}
-		} else {
-			fmt.Println("无效的数量输入")
-		}
-	}
-	saveRooms()
-	fmt.Println("房间信息更新成功")
-}
-
-// deleteRoom 删除房间（仅管理员操作）
-func deleteRoom() {
-	fmt.Print("请输入要删除的房间ID：")
-	idStr := readLine()
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Println("无效的ID")
-		return
-	}
-	index := -1
-	for i, room := range rooms {
-		if room.ID == id {
-			index = i
-			break
-		}
-	}
-	if index == -1 {
-		fmt.Println("未找到该房间")
-		return
-	}
-	fmt.Print("确定要删除该房间吗？(y/n): ")
-	confirm := readLine()
-	if confirm != "y" && confirm != "Y" {
-		return
-	}
-	rooms = append(rooms[:index], rooms[index+1:]...)
-	saveRooms()
-	fmt.Println("房间删除成功")
-}
-
-// ------------------------- 顾客功能 ----------------------------
-
-// customerMenu 为顾客提供房间查询、预订及查看余额的菜单
-func customerMenu(user *User) {
-	for {
-		fmt.Println("================================")
-		fmt.Println("顾客菜单")
-		fmt.Println("1. 查看房间信息")
-		fmt.Println("2. 预订房间")
-		fmt.Println("3. 查看余额")
-		fmt.Println("4. 退出")
-		fmt.Print("请选择操作：")
-		choice := readLine()
-		switch choice {
-		case "1":
-			listRooms()
-		case "2":
-			bookRoom(user)
-		case "3":
-			fmt.Printf("当前余额: %.2f\n", user.Balance)
-		case "4":
-			fmt.Println("注销成功")
-			saveUsers() // 保存余额变动
-			return
-		default:
-			fmt.Println("无效的选项，请重试。")
-		}
-	}
-}
-
-// bookRoom 实现顾客预订房间：检查房间剩余数量和余额，预订成功后扣款并更新房间状态
-func bookRoom(customer *User) {
-	if len(rooms) == 0 {
-		fmt.Println("当前无可预订的房间")
-		return
-	}
-	listRooms()
-	fmt.Print("请输入要预订的房间ID：")
-	idStr := readLine()
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		fmt.Println("无效的房间ID")
-		return
-	}
-	var room *Room
-	for i := range rooms {
-		if rooms[i].ID == id {
-			room = &rooms[i]
-			break
-		}
-	}
-	if room == nil {
-		fmt.Println("未找到该房间")
-		return
-	}
-	fmt.Printf("选择的房间: %s, 单价: %.2f, 剩余数量: %d\n", room.Type, room.Price, room.Available)
-	fmt.Print("请输入预订数量：")
-	quantityStr := readLine()
-	quantity, err := strconv.Atoi(quantityStr)
-	if err != nil || quantity <= 0 {
-		fmt.Println("无效的数量")
-		return
-	}
-	if quantity > room.Available {
-		fmt.Println("预订数量超过剩余房间数")
-		return
-	}
-	totalCost := room.Price * float64(quantity)
-	if customer.Balance < totalCost {
-		fmt.Println("余额不足，无法预订")
-		return
-	}
-	// 扣减余额并更新房间剩余数量
-	customer.Balance -= totalCost
-	room.Available -= quantity
-	saveUsers()
-	saveRooms()
-	fmt.Printf("预订成功！共扣款 %.2f 元，剩余余额: %.2f\n", totalCost, customer.Balance)
-}
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// User 定义了用户结构体，Role 字段为 "admin" 或 "customer"。
+// 对于顾客，CustomerType 表示会员或普通账号，Balance 表示账户余额。
+type User struct {
+	ID           int     `json:"id"`
+	Username     string  `json:"username"`
+	Password     string  `json:"password"`      // 密码哈希，格式见 hashPassword
+	Role         string  `json:"role"`          // "admin" 或 "customer"
+	CustomerType string  `json:"customer_type"` // "member" 或 "regular"，仅当 Role 为 "customer" 时有效
+	Balance      float64 `json:"balance"`       // 仅当 Role 为 "customer" 时有效
+	Deleted      bool    `json:"deleted"`       // 软删除标记，已删除的用户无法登录但保留记录用于审计
+	Points       int     `json:"points"`        // 积分余额，按 pointsForAmount 规则随预订累计
+	MemberTier   string  `json:"member_tier"`   // 会员等级名称，见 memberTiers；为空视为最低等级
+	// FavoriteRooms 为顾客收藏的房间 ID 列表，旧数据缺少该字段时为空
+	FavoriteRooms []int `json:"favorite_rooms"`
+	// CreatedAt 为注册时间，格式为 2006-01-02 15:04:05；旧数据缺少该字段时为空，统计时计为注册时间未知
+	CreatedAt string `json:"created_at"`
+}
+
+// Room 定义了酒店房间结构体，Available 表示当前剩余可预订数量。
+type Room struct {
+	ID        int     `json:"id"`
+	Type      string  `json:"type"`      // 房间类型，如单人间、双人间等
+	Price     float64 `json:"price"`     // 房间价格
+	Total     int     `json:"total"`     // 房间总数量
+	Available int     `json:"available"` // 当前剩余数量
+	// 以下为扩展信息，旧数据文件缺少这些字段时为空值
+	Description string   `json:"description"`  // 文字描述
+	Facilities  []string `json:"facilities"`   // 设施列表，如 wifi、空调
+	PricingUnit string   `json:"pricing_unit"` // 计价单位，pricingUnitNight 或 pricingUnitStay，为空视为按晚
+	Disabled    bool     `json:"disabled"`     // 是否已下架，下架的房间不可预订；旧数据缺少该字段时视为上架
+	Tags        []string `json:"tags"`         // 主题标签，如海景、商务、亲子；旧数据缺少该字段时为 nil
+	// Capacity 为每间可住人数，0 表示未设置，此时按标准房型推断，见 roomCapacity
+	Capacity int `json:"capacity"`
+	// Maintenance 为房间的维护窗口，窗口内的日期整体不可预订；旧数据缺少该字段时为空
+	Maintenance []MaintenanceWindow `json:"maintenance"`
+	// Units 为该房型下登记的具体房间号，数量不超过 Total，按楼层、房间号排列；
+	// 未登记房间号的房型（含旧数据）仍只按 Total、Available 计数管理库存
+	Units []RoomUnit `json:"units"`
+}
+
+// RoomUnit 定义了房型下的一个具体房间，房间号在全酒店范围内唯一
+type RoomUnit struct {
+	Number string `json:"number"` // 房间号，如 301
+	Floor  int    `json:"floor"`  // 所在楼层
+	Status string `json:"status"` // roomUnitActive 或 roomUnitDisabled
+}
+
+// 房间号的状态：停用的房间号（如维修中）不参与分配，但不影响房型的库存计数
+const (
+	roomUnitActive   = "active"
+	roomUnitDisabled = "disabled"
+)
+
+// MaintenanceWindow 定义了一段房间维护期，Start 到 End（含首尾两天）的每一晚都不可入住
+type MaintenanceWindow struct {
+	Start  string `json:"start"`  // 维护开始日期，格式为 2006-01-02
+	End    string `json:"end"`    // 维护结束日期（含当天），格式为 2006-01-02
+	Reason string `json:"reason"` // 维护原因，如装修、检修
+}
+
+// 房间的计价单位：按晚计费时费用随入住夜数增加，按次计费时整段入住只收一次
+const (
+	pricingUnitNight = "night"
+	pricingUnitStay  = "stay"
+)
+
+// Booking 定义了预订记录结构体，记录谁在什么时候预订了哪个房间、预订了几间。
+type Booking struct {
+	ID        int     `json:"id"`
+	UserID    int     `json:"user_id"`    // 预订人的用户 ID
+	RoomID    int     `json:"room_id"`    // 预订的房间 ID
+	Quantity  int     `json:"quantity"`   // 预订数量
+	TotalCost float64 `json:"total_cost"` // 实际扣款金额
+	Status    string  `json:"status"`     // 订单状态，取值及流转规则见 bookingTransitions
+	CheckIn   string  `json:"check_in"`   // 入住日期，格式为 2006-01-02
+	CheckOut  string  `json:"check_out"`  // 退房日期，格式为 2006-01-02
+	CreatedAt string  `json:"created_at"` // 下单时间，格式为 2006-01-02 15:04:05
+	Points    int     `json:"points"`     // 本单发放的积分，取消时按此扣回
+	UnitPrice float64 `json:"unit_price"` // 实际成交单价（每间每晚或每次，含动态定价上浮，不含会员折扣）
+	Note      string  `json:"note"`       // 顾客备注，如加床、无烟、晚到；最长 maxNoteLength 个字符
+	// OrderNo 为对顾客展示的订单号，格式见 nextOrderNo；旧数据缺少该字段时以 ID 代替，见 bookingNo
+	OrderNo string `json:"order_no"`
+	// 使用的优惠券及其抵扣金额，TotalCost 已扣除该金额；未使用优惠券时为空
+	CouponCode     string  `json:"coupon_code"`
+	CouponDiscount float64 `json:"coupon_discount"`
+	// Surcharge 为本单按周末、节假日逐晚加价的总额（折扣前），已计入 TotalCost；旧订单为 0
+	Surcharge float64 `json:"surcharge"`
+	// Items 为一次下单多个房间时的各房间明细，此时 RoomID、UnitPrice 为空，Quantity 为各项数量之和；
+	// 单个房间的订单不使用该字段，统一通过 bookingItems 读取
+	Items []BookingItem `json:"items,omitempty"`
+	// 管理员办理入住、退房的时间，格式同 CreatedAt；尚未办理时为空
+	CheckedInAt  string `json:"checked_in_at,omitempty"`
+	CheckedOutAt string `json:"checked_out_at,omitempty"`
+	// UnitNumbers 为分配给本单的具体房间号，多房间订单包含各项的房间号；
+	// 房型未登记房间号或空闲房间号不足时该项不分配，可由管理员稍后分配
+	UnitNumbers []string `json:"unit_numbers,omitempty"`
+	// 取消来源（见 cancelledBy* 常量）与管理员填写的取消原因；未取消或旧订单为空
+	CancelledBy  string `json:"cancelled_by,omitempty"`
+	CancelReason string `json:"cancel_reason,omitempty"`
+	// CancelFee 为取消时扣除的手续费（订单金额与实退金额之差），计入营收；全额退款、未取消或旧订单为 0
+	CancelFee float64 `json:"cancel_fee,omitempty"`
+}
+
+// BookingItem 是订单中一个房间的明细
+type BookingItem struct {
+	RoomID    int     `json:"room_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"` // 成交单价，含义同 Booking.UnitPrice
+	Cost      float64 `json:"cost"`       // 本项折后实付金额
+}
+
+const (
+	bookingStatusBooked     = "booked"
+	bookingStatusCheckedIn  = "checked_in"
+	bookingStatusCheckedOut = "checked_out"
+	bookingStatusCancelled  = "cancelled"
+)
+
+// 订单的取消来源：管理员取消（含删除房间、用户时的连带取消）或顾客自助取消
+const (
+	cancelledByAdmin    = "admin"
+	cancelledByCustomer = "customer"
+)
+
+// Transaction 定义了余额流水记录，每次余额变动都会生成一条，Amount 为正表示入账、为负表示扣款。
+type Transaction struct {
+	ID        int     `json:"id"`
+	UserID    int     `json:"user_id"`    // 余额变动的用户 ID
+	Amount    float64 `json:"amount"`     // 变动金额（正为入账，负为扣款）
+	Type      string  `json:"type"`       // 流水类型，见 transactionType* 常量
+	Note      string  `json:"note"`       // 说明
+	CreatedAt string  `json:"created_at"` // 发生时间，格式为 2006-01-02 15:04:05
+}
+
+const (
+	transactionTypeInitial  = "initial"  // 开户初始余额
+	transactionTypeBooking  = "booking"  // 预订扣款
+	transactionTypeRefund   = "refund"   // 退款
+	transactionTypeRecharge = "recharge" // 充值
+	transactionTypeAdjust   = "adjust"   // 管理员调整余额
+	transactionTypeFee      = "fee"      // 取消预订手续费
+	transactionTypeTransfer = "transfer" // 顾客间转账，转出为负、转入为正
+)
+
+// PriceChange 定义了一条房间价格变更记录。历史订单按成交时的 TotalCost 结算，不受改价影响，
+// 这里的记录只用于追溯调价过程和对账
+type PriceChange struct {
+	RoomID    int     `json:"room_id"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+	ChangedAt string  `json:"changed_at"` // 变更时间，格式为 2006-01-02 15:04:05
+	Operator  string  `json:"operator"`   // 执行改价的操作者
+}
+
+// Message 定义了一条站内消息，由预订成功、订单被取消、退款到账等事件自动生成，顾客在“我的消息”中查看
+type Message struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"` // 收件人的用户 ID
+	Type      string `json:"type"`    // 消息类型，见 messageType* 常量
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"` // 生成时间，格式为 2006-01-02 15:04:05
+	Read      bool   `json:"read"`       // 是否已读
+}
+
+const (
+	messageTypeBooking = "booking" // 预订成功
+	messageTypeCancel  = "cancel"  // 订单被管理员取消
+	messageTypeRefund  = "refund"  // 退款到账
+)
+
+// Review 定义了顾客对房间的一条评价，每个订单中的每个房间只能评价一次
+type Review struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`    // 评价人的用户 ID
+	RoomID    int    `json:"room_id"`    // 被评价的房间 ID
+	BookingID int    `json:"booking_id"` // 评价所依据的订单 ID
+	Rating    int    `json:"rating"`     // 评分，1 到 5
+	Comment   string `json:"comment"`    // 评价内容，可为空
+	CreatedAt string `json:"created_at"` // 评价时间，格式为 2006-01-02 15:04:05
+	// 以下为管理员的官方回复，未回复时为空；回复可修改，RepliedAt 记录最后一次修改的时间
+	Reply     string `json:"reply"`
+	RepliedBy string `json:"replied_by"` // 回复的管理员用户名
+	RepliedAt string `json:"replied_at"` // 回复时间，格式为 2006-01-02 15:04:05
+}
+
+// Coupon 定义了一张优惠券：在有效期内、使用次数未满时可在结算时抵扣，每位顾客限用一次，一笔订单只能用一张
+type Coupon struct {
+	Code       string  `json:"code"`        // 优惠码，保存为大写，输入时不区分大小写
+	Type       string  `json:"type"`        // couponTypeAmount 或 couponTypePercent
+	Value      float64 `json:"value"`       // 立减金额（元），或折扣百分比（如 20 表示减免 20%）
+	ValidFrom  string  `json:"valid_from"`  // 生效日期（含当天），格式为 2006-01-02
+	ValidUntil string  `json:"valid_until"` // 失效日期（含当天），格式为 2006-01-02
+	MaxUses    int     `json:"max_uses"`    // 总共可使用的次数
+	UsedCount  int     `json:"used_count"`  // 已使用的次数
+	UsedBy     []int   `json:"used_by"`     // 已使用过的顾客 ID
+	CreatedAt  string  `json:"created_at"`  // 创建时间，格式为 2006-01-02 15:04:05
+}
+
+// 优惠券的抵扣方式
+const (
+	couponTypeAmount  = "amount"  // 立减固定金额
+	couponTypePercent = "percent" // 按百分比减免
+)
+
+// timeLayout 是系统中记录时间所用的统一格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// dateLayout 是入住、退房日期的输入与存储格式
+const dateLayout = "2006-01-02"
+
+// memberTier 描述一个会员等级：累计消费达到 MinSpent 即可晋升，预订按 DiscountRate 打折
+type memberTier struct {
+	Name         string
+	MinSpent     float64
+	DiscountRate float64
+}
+
+// memberTiers 会员等级表，按 MinSpent 升序排列；仅 CustomerType 为 "member" 的顾客适用
+var memberTiers = []memberTier{
+	{Name: "普通会员", MinSpent: 0, DiscountRate: 0.9},
+	{Name: "银卡会员", MinSpent: 5000, DiscountRate: 0.85},
+	{Name: "金卡会员", MinSpent: 20000, DiscountRate: 0.8},
+}
+
+// yuanPerPoint 积分规则：实付金额每满 10 元累计 1 积分，不足部分不计
+const yuanPerPoint = 10
+
+// Settings 保存管理员可调整的系统设置
+type Settings struct {
+	DynamicPricing bool            `json:"dynamic_pricing"` // 是否启用按剩余比例的动态定价，见 computePrice
+	Language       string          `json:"language"`        // 界面语言，见 messages；为空时使用 defaultLanguage
+	RoomTypes      []roomTypeEntry `json:"room_types"`      // 标准房型字典，为空时使用 defaultRoomTypes
+	// LowBalanceThreshold 为顾客余额提醒阈值，为 0 时以最便宜的可预订房间价格为阈值
+	LowBalanceThreshold float64 `json:"low_balance_threshold"`
+	// RefundPolicy 为顾客取消预订时的退款策略，为空时使用 defaultRefundPolicy
+	RefundPolicy []refundRule `json:"refund_policy"`
+	// WeekendSurcharge、HolidaySurcharge 为周末夜和节假日每晚的加价比例（0.2 即加价 20%），为 0 表示不加价；
+	// Holidays 为节假日日期表，格式为 2006-01-02，按升序保存。规则见 nightRate
+	WeekendSurcharge float64  `json:"weekend_surcharge"`
+	HolidaySurcharge float64  `json:"holiday_surcharge"`
+	Holidays         []string `json:"holidays"`
+	// HotBookedThreshold 为房间详情标记“热门”所需的近 24 小时订出间数，LowStockThreshold 为提示“手慢无”的剩余间数；
+	// 为 0 时分别使用 defaultHotBookedThreshold、defaultLowStockThreshold，见 roomHeatLabel
+	HotBookedThreshold int `json:"hot_booked_threshold"`
+	LowStockThreshold  int `json:"low_stock_threshold"`
+}
+
+// refundRule 是退款策略中的一档：距入住日至少 MinDays 天取消时按 Ratio 比例退款
+type refundRule struct {
+	MinDays int     `json:"min_days"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// roomTypeEntry 是房型字典中的一个标准房型及其别名
+type roomTypeEntry struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// otherRoomType 为无法匹配任何标准房型时的兜底类型
+const otherRoomType = "其它"
+
+// defaultRoomTypes 为默认的标准房型字典
+var defaultRoomTypes = []roomTypeEntry{
+	{Name: "单人间", Aliases: []string{"单人房", "single"}},
+	{Name: "双人间", Aliases: []string{"双人房", "标准间", "double", "twin"}},
+	{Name: "大床房", Aliases: []string{"大床间", "king", "queen"}},
+	{Name: "套房", Aliases: []string{"套间", "suite"}},
+	{Name: "家庭房", Aliases: []string{"亲子房", "family"}},
+}
+
+// defaultRoomCapacities 为各标准房型的默认可住人数，房间未设置 Capacity 时按此推断
+var defaultRoomCapacities = map[string]int{
+	"单人间": 1,
+	"双人间": 2,
+	"大床房": 2,
+	"套房":  3,
+	"家庭房": 4,
+}
+
+// maxRoomCapacity 为单间可住人数的上限
+const maxRoomCapacity = 20
+
+// lowStockRatio 动态定价的库存阈值：剩余比例低于该值时价格上浮
+const lowStockRatio = 0.2
+
+// surgeRate 库存紧张时的价格上浮比例（0.2 即上浮 20%）
+const surgeRate = 0.2
+
+var settings Settings
+var users []User
+var rooms []Room
+var bookings []Booking
+var transactions []Transaction
+var priceHistory []PriceChange
+var inbox []Message
+var reviews []Review
+var coupons []Coupon
+
+// holds 为顾客确认订单前的临时占位，只保存在内存中，程序重启后自然失效
+var holds []roomHold
+
+// 锁的粒度：
+//   - dataMu 是保护内存中 users、rooms、bookings、holds 的全局互斥锁，
+//     用于"读库存-判断-写库存"这类必须原子完成的关键路径（如 bookRoom 的扣款下单）；
+//   - fileMu 只在 readDataFile/writeDataFile 内部持有，保证同一时刻只有一个 goroutine
+//     在读写数据文件，避免写入交错导致文件损坏。
+//
+// 两把锁的加锁顺序固定为先 dataMu 后 fileMu，不会产生死锁。
+var dataMu sync.Mutex
+var fileMu sync.Mutex
+
+const usersFile = "users.json"
+const roomsFile = "rooms.json"
+const bookingsFile = "bookings.json"
+const transactionsFile = "transactions.json"
+const settingsFile = "settings.json"
+const priceHistoryFile = "price_history.json"
+const messagesFile = "messages.json"
+const reviewsFile = "reviews.json"
+const couponsFile = "coupons.json"
+const logFile = "hotel.log"
+
+// dataDirEnv 是指定数据目录的环境变量名
+const dataDirEnv = "HOTEL_DATA_DIR"
+
+// dataDir 为所有数据文件所在的目录，默认为当前目录
+var dataDir = "."
+
+var reader = bufio.NewReader(os.Stdin)
+
+func main() {
+	dirFlag := flag.String("data", "", "数据文件目录（未指定时使用环境变量 "+dataDirEnv+"，默认为当前目录）")
+	flag.Parse()
+	if err := setDataDir(resolveDataDir(*dirFlag, os.Getenv(dataDirEnv))); err != nil {
+		fmt.Println("创建数据目录错误：", err)
+		os.Exit(1)
+	}
+
+	// 加载用户和房间数据
+	loadUsers()
+	loadRooms()
+	loadBookings()
+	loadTransactions()
+	loadSettings()
+	loadPriceHistory()
+	loadMessages()
+	loadReviews()
+	loadCoupons()
+	dataLoaded = true
+
+	// 带子命令运行时直接执行对应操作后退出，不进入交互菜单
+	if flag.NArg() > 0 {
+		currentOperator = "命令行"
+		os.Exit(runCommand(flag.Args()))
+	}
+	validateUsersIntegrity()
+	validateRoomsIntegrity()
+
+	for {
+		fmt.Println("================================")
+		fmt.Println(t("menu.main"))
+		printOptions(t("menu.main.login"), t("menu.main.register"), t("menu.main.language"), t("menu.main.exit"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			user := login()
+			if user != nil {
+				if user.Role == "admin" && ensureAdminPasswordChanged(user) {
+					runSession(adminMenu, user)
+				} else if user.Role == "customer" {
+					runSession(customerMenu, user)
+				}
+			}
+		case "2":
+			registerCustomer()
+		case "3":
+			chooseLanguage()
+		case "4":
+			fmt.Println(t("msg.exit"))
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// ------------------------- 命令行模式 ----------------------------
+
+// runCommand 执行非交互式子命令并返回进程退出码，便于脚本和自动化测试调用
+func runCommand(args []string) int {
+	switch args[0] {
+	case "listrooms":
+		printRooms(rooms)
+		return 0
+	case "listusers":
+		for _, user := range users {
+			printUser(user)
+		}
+		return 0
+	case "listbookings":
+		printBookings(bookings)
+		return 0
+	case "addroom":
+		return commandAddRoom(args[1:])
+	case "importrooms":
+		if len(args) != 2 {
+			fmt.Println("用法: importrooms <文件路径>")
+			return 2
+		}
+		result, err := importRooms(args[1])
+		if err != nil {
+			fmt.Println("导入房间错误：", err)
+			return 1
+		}
+		printImportResult(result)
+		if len(result.Skipped) > 0 {
+			return 1
+		}
+		return 0
+	case "help":
+		printCommandUsage()
+		return 0
+	default:
+		fmt.Printf("未知命令: %s\n", args[0])
+		printCommandUsage()
+		return 2
+	}
+}
+
+// commandAddRoom 实现 addroom 子命令，例如 addroom --type 单人间 --price 100 --total 5
+func commandAddRoom(args []string) int {
+	fs := flag.NewFlagSet("addroom", flag.ContinueOnError)
+	roomType := fs.String("type", "", "房间类型")
+	price := fs.Float64("price", 0, "房间价格（按晚计费时为每晚单价）")
+	total := fs.Int("total", 0, "房间总数")
+	description := fs.String("description", "", "房间描述")
+	facilities := fs.String("facilities", "", "房间设施，用逗号分隔")
+	unit := fs.String("unit", pricingUnitNight, "计价单位：night 按晚，stay 按次")
+	capacity := fs.Int("capacity", 0, "每间可住人数，0 表示按房型推断")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := validateRoomCapacity(*capacity); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	pricingUnit, err := parsePricingUnit(*unit)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if strings.TrimSpace(*roomType) == "" {
+		fmt.Println("必须通过 --type 指定房间类型")
+		return 2
+	}
+	if existing := findRoomByType(*roomType, 0); existing != nil {
+		fmt.Printf("房型“%s”已存在（ID: %d）\n", existing.Type, existing.ID)
+		return 1
+	}
+	priceWarning, err := validateRoomPrice(*price)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	totalWarning, err := validateRoomTotal(*total)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	// 非交互模式无法询问确认，异常数值只给出警告
+	for _, warning := range []string{priceWarning, totalWarning} {
+		if warning != "" {
+			fmt.Println("警告：" + warning)
+		}
+	}
+	room := createRoom(strings.TrimSpace(*roomType), *price, *total, *description, parseFacilities(*facilities), pricingUnit, *capacity)
+	fmt.Printf("房间添加成功！ID: %d\n", room.ID)
+	return 0
+}
+
+// printCommandUsage 打印子命令用法
+func printCommandUsage() {
+	fmt.Println("用法: hotel [-data 目录] [命令]")
+	fmt.Println("不带命令时进入交互菜单。可用命令：")
+	fmt.Println("  listrooms                                         列出所有房间")
+	fmt.Println("  listusers                                         列出所有用户")
+	fmt.Println("  listbookings                                      列出所有预订")
+	fmt.Println("  addroom --type 类型 --price 价格 --total 总数      添加房间（可选 --description、--facilities、--unit、--capacity）")
+	fmt.Println("  importrooms 文件路径                              从 CSV 或 JSON 文件批量导入房间")
+	fmt.Println("  help                                              显示本帮助")
+}
+
+// readLine 从标准输入读取一行数据并去掉末尾换行符。
+// 登录后的会话中若超过 sessionTimeout 无输入，则自动登出返回主菜单；
+// 标准输入结束（管道输入读完或 Ctrl+D）时保存数据后退出程序，避免菜单空转刷屏
+func readLine() string {
+	inputOnce.Do(startInputReader)
+	if !inSession {
+		line, ok := <-inputLines
+		if !ok {
+			exitOnInputEOF()
+		}
+		return line
+	}
+	warn := time.NewTimer(sessionTimeout - sessionWarningBefore)
+	defer warn.Stop()
+	expire := time.NewTimer(sessionTimeout)
+	defer expire.Stop()
+	for {
+		select {
+		case line, ok := <-inputLines:
+			if !ok {
+				exitOnInputEOF()
+			}
+			return line
+		case <-warn.C:
+			fmt.Printf("\n[提示] 您已长时间未操作，%d 秒后将自动登出。\n", int(sessionWarningBefore.Seconds()))
+		case <-expire.C:
+			panic(errSessionTimeout)
+		}
+	}
+}
+
+// 会话空闲超时设置：超过 sessionTimeout 无输入自动登出，提前 sessionWarningBefore 给出提示
+const sessionTimeout = 5 * time.Minute
+const sessionWarningBefore = 30 * time.Second
+
+// errSessionTimeout 是会话空闲超时时由 readLine 抛出、在 runSession 中捕获的信号
+var errSessionTimeout = errors.New("会话空闲超时")
+
+// inSession 表示当前是否处于登录后的会话中，只有会话中的输入才会超时
+var inSession bool
+
+// dataLoaded 表示启动时的数据文件是否已全部加载完成。加载完成前内存中的数据不完整，
+// 此时保存会用空数据覆盖尚未加载的文件
+var dataLoaded bool
+
+// exitOnInputEOF 在标准输入结束时保存所有数据并正常退出；数据尚未加载完成时
+// （如在损坏文件的恢复提示处读到输入结束）不保存任何数据，以非零退出码退出
+func exitOnInputEOF() {
+	// 可能正在以关闭回显的方式读取密码，退出前恢复终端回显
+	if stdinIsTerminal() {
+		setTerminalEcho(true)
+	}
+	if !dataLoaded {
+		fmt.Println("\n输入已结束，数据尚未加载完成，未修改任何数据，退出系统")
+		os.Exit(1)
+	}
+	saveAllData()
+	if currentOperator != "" {
+		logOperation(operatorName(), "登出", "输入结束，自动退出")
+	}
+	fmt.Println("\n输入已结束，数据已保存，退出系统")
+	os.Exit(0)
+}
+
+// inputLines 由后台 goroutine 逐行读取标准输入后发送，输入结束时关闭
+var inputLines = make(chan string)
+var inputOnce sync.Once
+
+// startInputReader 启动后台 goroutine 读取标准输入，使 readLine 可以带超时地等待输入
+func startInputReader() {
+	go func() {
+		for {
+			input, err := reader.ReadString('\n')
+			if err != nil {
+				if input != "" {
+					inputLines <- strings.TrimSpace(input)
+				}
+				close(inputLines)
+				return
+			}
+			inputLines <- strings.TrimSpace(input)
+		}
+	}()
+}
+
+// runSession 运行登录后的菜单。菜单中任意一次输入空闲超时都会中断当前操作，
+// 保存数据后自动登出返回主菜单
+func runSession(menu func(*User), user *User) {
+	inSession = true
+	currentOperator = user.Username
+	defer func() {
+		inSession = false
+		logOperation(operatorName(), "登出", "成功")
+		currentOperator = ""
+		if r := recover(); r != nil {
+			if r != errSessionTimeout {
+				panic(r)
+			}
+			fmt.Println("\n长时间未操作，已自动登出。")
+			saveAllData()
+		}
+	}()
+	menu(user)
+}
+
+// saveAllData 保存所有数据文件
+func saveAllData() {
+	saveUsers()
+	saveRooms()
+	saveBookings()
+	saveTransactions()
+	saveSettings()
+	savePriceHistory()
+	saveMessages()
+	saveReviews()
+	saveCoupons()
+}
+
+// readPassword 读取一行密码且不在终端回显。通过 stty 关闭回显，
+// 标准输入不是终端（如管道输入测试）或 stty 不可用时回退到普通读取
+func readPassword() string {
+	if !stdinIsTerminal() || setTerminalEcho(false) != nil {
+		return readLine()
+	}
+	defer setTerminalEcho(true)
+	password := readLine()
+	// 回车没有被回显，手动换行保持后续输出整齐
+	fmt.Println()
+	return password
+}
+
+// stdinIsTerminal 判断标准输入是否为终端
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setTerminalEcho 打开或关闭终端回显
+func setTerminalEcho(on bool) error {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// ------------------------- 数据持久化相关 ----------------------------
+
+// resolveDataDir 按 命令行参数 > 环境变量 > 当前目录 的优先级确定数据目录
+func resolveDataDir(flagValue, envValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if envValue != "" {
+		return envValue
+	}
+	return "."
+}
+
+// setDataDir 设置数据目录，目录不存在时自动创建
+func setDataDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dataDir = dir
+	return nil
+}
+
+// dataPath 返回数据文件在数据目录下的完整路径
+func dataPath(name string) string {
+	return filepath.Join(dataDir, name)
+}
+
+// readDataFile 在 fileMu 保护下读取数据目录中的文件
+func readDataFile(name string) ([]byte, error) {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	return ioutil.ReadFile(dataPath(name))
+}
+
+// writeDataFile 在 fileMu 保护下原子地写入数据目录中的文件：先写入同目录下的临时文件，
+// 再用 os.Rename 覆盖目标文件，即使写入中途崩溃，目标文件也只会是完整的旧数据或新数据
+func writeDataFile(name string, data []byte) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	path := dataPath(name)
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// 出错时清理临时文件；重命名成功后临时文件已不存在，Remove 会直接返回错误而无副作用
+	defer os.Remove(tmpName)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+// 加载用户数据，如果文件不存在则初始化默认管理员账号
+func loadUsers() {
+	data, err := readDataFile(usersFile)
+	if err != nil {
+		// 文件不存在，初始化默认管理员账号
+		fmt.Println("未找到用户数据文件，初始化默认管理员账号。")
+		initDefaultUsers()
+		return
+	}
+	err = json.Unmarshal(data, &users)
+	if err != nil {
+		fmt.Println("加载用户数据错误：", err)
+		recoverCorruptFile(usersFile, data)
+		fmt.Println("已重新初始化默认管理员账号。")
+		initDefaultUsers()
+		return
+	}
+	migratePlainPasswords()
+}
+
+// defaultAdminPassword 为初始化时默认管理员账号的密码，使用该密码登录后必须先修改密码
+const defaultAdminPassword = "admin"
+
+// initDefaultUsers 把用户列表初始化为仅含默认管理员账号并保存
+func initDefaultUsers() {
+	users = []User{
+		{
+			ID:        1,
+			Username:  "admin",
+			Password:  hashPassword(defaultAdminPassword),
+			Role:      "admin",
+			CreatedAt: time.Now().Format(timeLayout),
+		},
+	}
+	saveUsers()
+	fmt.Printf("已创建默认管理员账号 admin，初始密码为 %s，首次登录后需修改密码。\n", defaultAdminPassword)
+}
+
+// recoverCorruptFile 在数据文件解析失败时把损坏的内容备份为 <文件名>.bak，
+// 并让用户选择重新初始化数据或退出程序手动修复；只有选择重新初始化时才会返回
+func recoverCorruptFile(name string, data []byte) {
+	backup := name + ".bak"
+	if err := writeDataFile(backup, data); err != nil {
+		fmt.Println("备份损坏的数据文件错误：", err)
+		os.Exit(1)
+	}
+	path := dataPath(name)
+	backup = dataPath(backup)
+	fmt.Printf("数据文件 %s 已损坏，原内容已备份到 %s\n", path, backup)
+	fmt.Print("请选择操作（1. 重新初始化该数据 2. 退出并手动修复）：")
+	if readLine() != "1" {
+		fmt.Printf("已退出，请修复 %s 或从 %s 恢复后重新运行。\n", path, backup)
+		os.Exit(1)
+	}
+}
+
+// migratePlainPasswords 把旧数据文件中的明文密码一次性转换为哈希并保存
+func migratePlainPasswords() {
+	migrated := 0
+	for i := range users {
+		if !isHashedPassword(users[i].Password) {
+			users[i].Password = hashPassword(users[i].Password)
+			migrated++
+		}
+	}
+	if migrated > 0 {
+		fmt.Printf("已将 %d 个明文密码转换为哈希存储。\n", migrated)
+		saveUsers()
+	}
+}
+
+// 保存用户数据到文件
+func saveUsers() {
+	data, err := json.MarshalIndent(users, "", "  ")
+	if err != nil {
+		fmt.Println("保存用户数据错误：", err)
+		return
+	}
+	err = writeDataFile(usersFile, data)
+	if err != nil {
+		fmt.Println("写入用户数据文件错误：", err)
+	}
+}
+
+// 加载房间数据，如果文件不存在则初始化为空房间列表
+func loadRooms() {
+	data, err := readDataFile(roomsFile)
+	if err != nil {
+		fmt.Println("未找到房间数据文件，初始化空房间列表。")
+		rooms = []Room{}
+		saveRooms()
+		return
+	}
+	err = json.Unmarshal(data, &rooms)
+	if err != nil {
+		fmt.Println("加载房间数据错误：", err)
+		recoverCorruptFile(roomsFile, data)
+		fmt.Println("已重新初始化空房间列表。")
+		rooms = []Room{}
+		saveRooms()
+	}
+}
+
+// 保存房间数据到文件
+func saveRooms() {
+	data, err := json.MarshalIndent(rooms, "", "  ")
+	if err != nil {
+		fmt.Println("保存房间数据错误：", err)
+		return
+	}
+	err = writeDataFile(roomsFile, data)
+	if err != nil {
+		fmt.Println("写入房间数据文件错误：", err)
+	}
+}
+
+// 加载预订数据，如果文件不存在则初始化为空预订列表
+func loadBookings() {
+	data, err := readDataFile(bookingsFile)
+	if err != nil {
+		fmt.Println("未找到预订数据文件，初始化空预订列表。")
+		bookings = []Booking{}
+		saveBookings()
+		return
+	}
+	err = json.Unmarshal(data, &bookings)
+	if err != nil {
+		fmt.Println("加载预订数据错误：", err)
+		recoverCorruptFile(bookingsFile, data)
+		fmt.Println("已重新初始化空预订列表。")
+		bookings = []Booking{}
+		saveBookings()
+	}
+}
+
+// 保存预订数据到文件
+func saveBookings() {
+	data, err := json.MarshalIndent(bookings, "", "  ")
+	if err != nil {
+		fmt.Println("保存预订数据错误：", err)
+		return
+	}
+	err = writeDataFile(bookingsFile, data)
+	if err != nil {
+		fmt.Println("写入预订数据文件错误：", err)
+	}
+}
+
+// 加载余额流水数据，如果文件不存在则初始化为空流水列表
+func loadTransactions() {
+	data, err := readDataFile(transactionsFile)
+	if err != nil {
+		fmt.Println("未找到流水数据文件，初始化空流水列表。")
+		transactions = []Transaction{}
+		saveTransactions()
+		return
+	}
+	err = json.Unmarshal(data, &transactions)
+	if err != nil {
+		fmt.Println("加载流水数据错误：", err)
+		recoverCorruptFile(transactionsFile, data)
+		fmt.Println("已重新初始化空流水列表。")
+		transactions = []Transaction{}
+		saveTransactions()
+	}
+}
+
+// 保存余额流水数据到文件
+func saveTransactions() {
+	data, err := json.MarshalIndent(transactions, "", "  ")
+	if err != nil {
+		fmt.Println("保存流水数据错误：", err)
+		return
+	}
+	err = writeDataFile(transactionsFile, data)
+	if err != nil {
+		fmt.Println("写入流水数据文件错误：", err)
+	}
+}
+
+// 加载房间价格变更历史，如果文件不存在则初始化为空列表
+func loadPriceHistory() {
+	data, err := readDataFile(priceHistoryFile)
+	if err != nil {
+		fmt.Println("未找到价格历史文件，初始化空价格历史。")
+		priceHistory = []PriceChange{}
+		savePriceHistory()
+		return
+	}
+	err = json.Unmarshal(data, &priceHistory)
+	if err != nil {
+		fmt.Println("加载价格历史错误：", err)
+		recoverCorruptFile(priceHistoryFile, data)
+		fmt.Println("已重新初始化空价格历史。")
+		priceHistory = []PriceChange{}
+		savePriceHistory()
+	}
+}
+
+// 保存房间价格变更历史到文件
+func savePriceHistory() {
+	data, err := json.MarshalIndent(priceHistory, "", "  ")
+	if err != nil {
+		fmt.Println("保存价格历史错误：", err)
+		return
+	}
+	err = writeDataFile(priceHistoryFile, data)
+	if err != nil {
+		fmt.Println("写入价格历史文件错误：", err)
+	}
+}
+
+// 加载站内消息，如果文件不存在则初始化为空列表
+func loadMessages() {
+	data, err := readDataFile(messagesFile)
+	if err != nil {
+		fmt.Println("未找到消息数据文件，初始化空消息列表。")
+		inbox = []Message{}
+		saveMessages()
+		return
+	}
+	err = json.Unmarshal(data, &inbox)
+	if err != nil {
+		fmt.Println("加载消息数据错误：", err)
+		recoverCorruptFile(messagesFile, data)
+		fmt.Println("已重新初始化空消息列表。")
+		inbox = []Message{}
+		saveMessages()
+	}
+}
+
+// 保存站内消息到文件
+func saveMessages() {
+	data, err := json.MarshalIndent(inbox, "", "  ")
+	if err != nil {
+		fmt.Println("保存消息数据错误：", err)
+		return
+	}
+	err = writeDataFile(messagesFile, data)
+	if err != nil {
+		fmt.Println("写入消息数据文件错误：", err)
+	}
+}
+
+// 加载房间评价，如果文件不存在则初始化为空列表
+func loadReviews() {
+	data, err := readDataFile(reviewsFile)
+	if err != nil {
+		fmt.Println("未找到评价数据文件，初始化空评价列表。")
+		reviews = []Review{}
+		saveReviews()
+		return
+	}
+	err = json.Unmarshal(data, &reviews)
+	if err != nil {
+		fmt.Println("加载评价数据错误：", err)
+		recoverCorruptFile(reviewsFile, data)
+		fmt.Println("已重新初始化空评价列表。")
+		reviews = []Review{}
+		saveReviews()
+	}
+}
+
+// 保存房间评价到文件
+func saveReviews() {
+	data, err := json.MarshalIndent(reviews, "", "  ")
+	if err != nil {
+		fmt.Println("保存评价数据错误：", err)
+		return
+	}
+	err = writeDataFile(reviewsFile, data)
+	if err != nil {
+		fmt.Println("写入评价数据文件错误：", err)
+	}
+}
+
+// 加载优惠券，如果文件不存在则初始化为空列表
+func loadCoupons() {
+	data, err := readDataFile(couponsFile)
+	if err != nil {
+		fmt.Println("未找到优惠券数据文件，初始化空优惠券列表。")
+		coupons = []Coupon{}
+		saveCoupons()
+		return
+	}
+	err = json.Unmarshal(data, &coupons)
+	if err != nil {
+		fmt.Println("加载优惠券数据错误：", err)
+		recoverCorruptFile(couponsFile, data)
+		fmt.Println("已重新初始化空优惠券列表。")
+		coupons = []Coupon{}
+		saveCoupons()
+	}
+}
+
+// 保存优惠券到文件
+func saveCoupons() {
+	data, err := json.MarshalIndent(coupons, "", "  ")
+	if err != nil {
+		fmt.Println("保存优惠券数据错误：", err)
+		return
+	}
+	err = writeDataFile(couponsFile, data)
+	if err != nil {
+		fmt.Println("写入优惠券数据文件错误：", err)
+	}
+}
+
+// 加载系统设置，如果文件不存在则使用默认设置
+func loadSettings() {
+	data, err := readDataFile(settingsFile)
+	if err != nil {
+		fmt.Println("未找到设置文件，使用默认设置。")
+		settings = Settings{}
+		saveSettings()
+		return
+	}
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		fmt.Println("加载设置错误：", err)
+		recoverCorruptFile(settingsFile, data)
+		fmt.Println("已恢复为默认设置。")
+		settings = Settings{}
+		saveSettings()
+	}
+}
+
+// 保存系统设置到文件
+func saveSettings() {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		fmt.Println("保存设置错误：", err)
+		return
+	}
+	err = writeDataFile(settingsFile, data)
+	if err != nil {
+		fmt.Println("写入设置文件错误：", err)
+	}
+}
+
+// roomIssue 描述一个房间的库存异常，Expected 为按未取消预订推算出的正确剩余数量
+type roomIssue struct {
+	RoomID   int
+	Problems []string
+	Expected int
+}
+
+// bookedQuantity 统计房间所有仍占用库存的预订（已预订或已入住）的数量
+func bookedQuantity(roomID int) int {
+	count := 0
+	for _, booking := range bookings {
+		if bookingHoldsRoom(booking) {
+			count += bookingRoomQuantity(booking, roomID)
+		}
+	}
+	return count
+}
+
+// roomIntegrityIssues 扫描所有房间，找出 Available 为负、超过 Total 或与未取消预订数量不符的房间
+func roomIntegrityIssues() []roomIssue {
+	var issues []roomIssue
+	for _, room := range rooms {
+		booked := bookedQuantity(room.ID)
+		expected := room.Total - booked
+		var problems []string
+		if room.Available < 0 {
+			problems = append(problems, fmt.Sprintf("剩余数量为负数（%d）", room.Available))
+		}
+		if room.Available > room.Total {
+			problems = append(problems, fmt.Sprintf("剩余数量 %d 大于总数 %d", room.Available, room.Total))
+		}
+		if expected < 0 {
+			problems = append(problems, fmt.Sprintf("未取消预订共 %d 间，超过总数 %d", booked, room.Total))
+			expected = 0
+		}
+		if room.Available != expected && len(problems) == 0 {
+			problems = append(problems, fmt.Sprintf("剩余数量 %d 与总数减去未取消预订（%d - %d）不符", room.Available, room.Total, booked))
+		}
+		if len(problems) > 0 {
+			issues = append(issues, roomIssue{RoomID: room.ID, Problems: problems, Expected: expected})
+		}
+	}
+	return issues
+}
+
+// validateRoomsIntegrity 启动时检查房间库存是否一致，发现异常时打印报告并由管理员决定是否按预订记录自动修复
+func validateRoomsIntegrity() {
+	issues := roomIntegrityIssues()
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Printf("数据自检发现 %d 个房间库存异常：\n", len(issues))
+	for _, issue := range issues {
+		fmt.Printf("房间 %d（%s）：%s，应为 %d\n", issue.RoomID, roomTypeName(issue.RoomID),
+			strings.Join(issue.Problems, "；"), issue.Expected)
+	}
+	fmt.Print("是否按预订记录自动修复剩余数量？(y/n): ")
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		fmt.Println("未修复，请管理员检查数据")
+		return
+	}
+	for _, issue := range issues {
+		if room := findRoomByID(issue.RoomID); room != nil {
+			room.Available = issue.Expected
+		}
+	}
+	saveRooms()
+	logOperation(operatorName(), "数据自检修复房间库存", fmt.Sprintf("成功，修复 %d 个房间", len(issues)))
+	fmt.Printf("已修复 %d 个房间的剩余数量\n", len(issues))
+}
+
+// 用户冲突的类型
+const (
+	userConflictID       = "id"       // 多个用户记录使用同一个 ID
+	userConflictUsername = "username" // 多个未删除的用户使用同一个用户名
+)
+
+// userConflict 描述一组互相冲突的用户记录，Indexes 为它们在列表中的下标，按出现顺序排列
+type userConflict struct {
+	Kind    string
+	Key     string // 冲突的 ID 或用户名
+	Indexes []int
+}
+
+// userConflicts 找出 list 中的重复 ID（含已删除用户）和未删除用户之间的重复用户名，
+// 先列出 ID 冲突再列出用户名冲突，各自按首次出现的顺序排列
+func userConflicts(list []User) []userConflict {
+	var conflicts []userConflict
+	byID := make(map[int][]int)
+	var ids []int
+	byName := make(map[string][]int)
+	var names []string
+	for i, user := range list {
+		if len(byID[user.ID]) == 0 {
+			ids = append(ids, user.ID)
+		}
+		byID[user.ID] = append(byID[user.ID], i)
+		if user.Deleted {
+			continue
+		}
+		if len(byName[user.Username]) == 0 {
+			names = append(names, user.Username)
+		}
+		byName[user.Username] = append(byName[user.Username], i)
+	}
+	for _, id := range ids {
+		if len(byID[id]) > 1 {
+			conflicts = append(conflicts, userConflict{Kind: userConflictID, Key: strconv.Itoa(id), Indexes: byID[id]})
+		}
+	}
+	for _, name := range names {
+		if len(byName[name]) > 1 {
+			conflicts = append(conflicts, userConflict{Kind: userConflictUsername, Key: name, Indexes: byName[name]})
+		}
+	}
+	return conflicts
+}
+
+// validateUsersIntegrity 启动时检查重复的用户 ID 和用户名，发现冲突时打印报告，
+// 并由管理员逐一处理：每组冲突保留第一条记录，其余记录可重新分配 ID / 重命名或删除
+func validateUsersIntegrity() {
+	conflicts := userConflicts(users)
+	if len(conflicts) == 0 {
+		return
+	}
+	fmt.Printf("数据自检发现 %d 组用户冲突：\n", len(conflicts))
+	for _, conflict := range conflicts {
+		label := "用户 ID"
+		if conflict.Kind == userConflictUsername {
+			label = "用户名"
+		}
+		var members []string
+		for _, index := range conflict.Indexes {
+			members = append(members, fmt.Sprintf("第 %d 条（ID: %d, 用户名: %s, 角色: %s）",
+				index+1, users[index].ID, users[index].Username, users[index].Role))
+		}
+		fmt.Printf("%s %s 重复：%s\n", label, conflict.Key, strings.Join(members, "；"))
+	}
+	fmt.Print("是否现在逐一处理？(y/n): ")
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		fmt.Println("未处理，登录时将匹配到排在前面的用户，请管理员尽快检查数据")
+		return
+	}
+	// 先处理 ID 冲突：删除记录会改变下标，处理完后再重新检测用户名冲突
+	var removed []int
+	for _, conflict := range userConflicts(users) {
+		if conflict.Kind != userConflictID {
+			continue
+		}
+		for _, index := range conflict.Indexes[1:] {
+			user := &users[index]
+			fmt.Printf("ID %d 重复：第 %d 条记录（用户名: %s）。该 ID 的预订和流水仍归属第一条记录\n", user.ID, index+1, user.Username)
+			fmt.Print("请选择（1. 分配新 ID 2. 删除该记录 其它. 跳过）：")
+			switch readLine() {
+			case "1":
+				user.ID = getNextUserID()
+				fmt.Printf("已为用户 %s 分配新 ID %d\n", user.Username, user.ID)
+			case "2":
+				removed = append(removed, index)
+			}
+		}
+	}
+	if len(removed) > 0 {
+		sort.Sort(sort.Reverse(sort.IntSlice(removed)))
+		for _, index := range removed {
+			users = append(users[:index], users[index+1:]...)
+		}
+		fmt.Printf("已删除 %d 条重复 ID 的用户记录\n", len(removed))
+	}
+	for _, conflict := range userConflicts(users) {
+		if conflict.Kind != userConflictUsername {
+			continue
+		}
+		for _, index := range conflict.Indexes[1:] {
+			user := &users[index]
+			fmt.Printf("用户名 %s 重复：ID %d（角色: %s）\n", user.Username, user.ID, user.Role)
+			fmt.Print("请选择（1. 重命名 2. 删除该用户 其它. 跳过）：")
+			switch readLine() {
+			case "1":
+				fmt.Print("请输入新的用户名：")
+				name := readLine()
+				if err := validateUsername(name); err != nil {
+					fmt.Printf("%v，已跳过\n", err)
+				} else if usernameExists(name) {
+					fmt.Println("该用户名已被使用，已跳过")
+				} else {
+					user.Username = name
+					fmt.Printf("ID %d 已重命名为 %s\n", user.ID, name)
+				}
+			case "2":
+				user.Deleted = true
+				fmt.Printf("已删除 ID %d 的用户\n", user.ID)
+			}
+		}
+	}
+	saveUsers()
+	remaining := len(userConflicts(users))
+	logOperation(operatorName(), "数据自检处理用户冲突", fmt.Sprintf("成功，剩余 %d 组冲突", remaining))
+	if remaining > 0 {
+		fmt.Printf("仍有 %d 组用户冲突未处理，请管理员检查数据\n", remaining)
+	} else {
+		fmt.Println("用户冲突已全部处理")
+	}
+}
+
+// ------------------------- 操作日志 ----------------------------
+
+// logMu 保证多条日志追加写入时不会交错
+var logMu sync.Mutex
+
+// currentOperator 为当前登录用户的用户名，未登录时为空；命令行模式下为 "命令行"
+var currentOperator string
+
+// operatorName 返回记录日志时使用的操作者名称
+func operatorName() string {
+	if currentOperator == "" {
+		return "访客"
+	}
+	return currentOperator
+}
+
+// logOperation 以"时间 | 操作者 | 动作 | 结果"的格式向 hotel.log 追加一条操作日志，
+// 写日志失败只提示不影响业务
+func logOperation(operator, action, result string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	f, err := os.OpenFile(dataPath(logFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Println("写入操作日志错误：", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s | %s | %s | %s\n", time.Now().Format(timeLayout), operator, action, result); err != nil {
+		fmt.Println("写入操作日志错误：", err)
+	}
+}
+
+// resultOf 把操作错误转换为日志中的结果描述
+func resultOf(err error) string {
+	if err != nil {
+		return "失败：" + err.Error()
+	}
+	return "成功"
+}
+
+// ------------------------- 密码哈希 ----------------------------
+
+// passwordHashPrefix 标识哈希密码的前缀，用于区分旧的明文密码
+const passwordHashPrefix = "sha256$"
+
+// hashPassword 生成随机盐并返回 "sha256$<盐>$<哈希>" 格式的密码哈希
+func hashPassword(password string) string {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		fmt.Println("生成密码盐错误：", err)
+		os.Exit(1)
+	}
+	saltHex := hex.EncodeToString(salt)
+	return passwordHashPrefix + saltHex + "$" + sha256Hex(saltHex, password)
+}
+
+// checkPassword 比对输入的密码与存储的哈希是否一致
+func checkPassword(stored, password string) bool {
+	parts := strings.Split(strings.TrimPrefix(stored, passwordHashPrefix), "$")
+	if !isHashedPassword(stored) || len(parts) != 2 {
+		return false
+	}
+	expected := sha256Hex(parts[0], password)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(parts[1])) == 1
+}
+
+// isHashedPassword 判断存储的密码是否已经是哈希格式
+func isHashedPassword(stored string) bool {
+	return strings.HasPrefix(stored, passwordHashPrefix)
+}
+
+// sha256Hex 计算 盐+密码 的 sha256 十六进制摘要
+func sha256Hex(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// 密码强度规则
+const (
+	minPasswordLength   = 6    // 密码最少长度
+	maxPasswordLength   = 64   // 密码最大长度
+	passwordNeedsLetter = true // 是否必须包含字母
+	passwordNeedsDigit  = true // 是否必须包含数字
+)
+
+// validatePassword 校验密码强度，不满足规则时返回具体原因
+func validatePassword(pw string) error {
+	length := len([]rune(pw))
+	if length < minPasswordLength {
+		return fmt.Errorf("密码长度不能少于 %d 位", minPasswordLength)
+	}
+	if length > maxPasswordLength {
+		return fmt.Errorf("密码长度不能超过 %d 位", maxPasswordLength)
+	}
+	hasLetter, hasDigit := false, false
+	for _, r := range pw {
+		switch {
+		case unicode.IsSpace(r):
+			return errors.New("密码不能包含空白字符")
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsLetter(r):
+			hasLetter = true
+		}
+	}
+	if passwordNeedsLetter && !hasLetter {
+		return errors.New("密码必须至少包含一个字母")
+	}
+	if passwordNeedsDigit && !hasDigit {
+		return errors.New("密码必须至少包含一个数字")
+	}
+	return nil
+}
+
+// 用户名长度范围
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+)
+
+// validateUsername 校验用户名格式：长度在范围内，且只允许字母、数字和下划线
+func validateUsername(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("用户名不能为空")
+	}
+	if len(name) < minUsernameLength || len(name) > maxUsernameLength {
+		return fmt.Errorf("用户名长度必须在 %d 到 %d 个字符之间", minUsernameLength, maxUsernameLength)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return fmt.Errorf("用户名只能包含字母、数字和下划线，不允许字符 %q", r)
+		}
+	}
+	return nil
+}
+
+// usernameExists 检查用户名是否已被使用，已删除用户的用户名仍被保留以免审计记录混淆
+func usernameExists(name string) bool {
+	for _, user := range users {
+		if user.Username == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------- 多语言 ----------------------------
+
+// defaultLanguage 为默认界面语言，其它语言缺失的文案回退到该语言
+const defaultLanguage = "zh"
+
+// languages 为可选的界面语言，按菜单显示顺序排列
+var languages = []struct {
+	Code string
+	Name string
+}{
+	{"zh", "中文"},
+	{"en", "English"},
+}
+
+// messages 保存界面文案：语言 → 键 → 文案
+var messages = map[string]map[string]string{
+	"zh": {
+		"menu.main":                 "欢迎使用酒店管理系统",
+		"menu.main.login":           "登录",
+		"menu.main.register":        "注册（仅限顾客）",
+		"menu.main.language":        "切换语言 / Language",
+		"menu.main.exit":            "退出",
+		"menu.admin":                "管理员菜单",
+		"menu.admin.users":          "用户管理",
+		"menu.admin.rooms":          "房间管理",
+		"menu.admin.bookings":       "预订管理",
+		"menu.admin.statistics":     "统计报表",
+		"menu.admin.export":         "导出数据",
+		"menu.admin.transactions":   "交易流水",
+		"menu.admin.settings":       "系统设置",
+		"menu.admin.backup":         "数据备份与恢复",
+		"menu.admin.reviews":        "评价管理",
+		"menu.backup":               "--------- 数据备份与恢复 ---------",
+		"menu.backup.create":        "备份全部数据",
+		"menu.backup.restore":       "从备份包恢复",
+		"menu.stats":                "--------- 统计报表 ---------",
+		"menu.stats.overview":       "营收与入住率概览",
+		"menu.stats.top_spenders":   "顾客消费排行榜",
+		"menu.stats.revenue_range":  "按日期区间查询营收",
+		"menu.stats.user_growth":    "用户增长",
+		"menu.settings":             "--------- 系统设置 ---------",
+		"menu.settings.low_balance": "余额提醒阈值（当前：%s）",
+		"menu.settings.refund":      "退款策略（当前：%s）",
+		"menu.settings.coupons":     "优惠券管理",
+		"menu.settings.surcharge":   "周末/节假日加价（当前：%s）",
+		"menu.settings.heat":        "热度提醒阈值（当前：%s）",
+		"menu.users":                "--------- 用户管理 ---------",
+		"menu.users.list":           "查看所有用户",
+		"menu.users.add":            "添加用户",
+		"menu.users.update":         "修改用户",
+		"menu.users.delete":         "删除用户",
+		"menu.users.reset_password": "重置顾客密码",
+		"menu.users.toggle_deleted": "显示/隐藏已删除用户",
+		"menu.users.adjust_balance": "调整顾客余额",
+		"menu.users.search":         "搜索用户",
+		"menu.rooms":                "--------- 房间管理 ---------",
+		"menu.rooms.list":           "查看所有房间",
+		"menu.rooms.add":            "添加房间",
+		"menu.rooms.update":         "修改房间",
+		"menu.rooms.delete":         "删除房间",
+		"menu.rooms.search":         "搜索房间",
+		"menu.rooms.dynamic":        "动态定价开关（当前：%s）",
+		"menu.rooms.import":         "批量导入房间",
+		"menu.rooms.types":          "房型字典管理",
+		"menu.rooms.calendar":       "房间可用日历",
+		"menu.rooms.toggle":         "上架/下架房间",
+		"menu.rooms.price_history":  "查看价格变更历史",
+		"menu.rooms.bulk_price":     "批量调价",
+		"menu.rooms.undo":           "撤销上一步",
+		"menu.rooms.maintenance":    "维护窗口",
+		"menu.rooms.units":          "房间号管理",
+		"menu.types":                "--------- 房型字典 ---------",
+		"menu.types.list":           "查看标准房型",
+		"menu.types.add":            "添加标准房型",
+		"menu.types.alias":          "为标准房型添加别名",
+		"menu.types.delete":         "删除标准房型",
+		"menu.bookings":             "--------- 预订管理 ---------",
+		"menu.bookings.all":         "查看所有预订",
+		"menu.bookings.by_user":     "按用户ID过滤",
+		"menu.bookings.by_room":     "按房间ID过滤",
+		"menu.bookings.by_status":   "按状态过滤",
+		"menu.bookings.check_in":    "办理入住",
+		"menu.bookings.check_out":   "办理退房",
+		"menu.bookings.in_house":    "在住客人",
+		"menu.bookings.units":       "分配房间号",
+		"menu.bookings.cancel":      "取消订单",
+		"menu.customer":             "顾客菜单",
+		"menu.customer.rooms":       "查看房间信息",
+		"menu.customer.book":        "预订房间",
+		"menu.customer.balance":     "查看余额",
+		"menu.customer.recharge":    "充值",
+		"menu.customer.search":      "搜索房间",
+		"menu.customer.filter":      "按价格筛选",
+		"menu.customer.bookings":    "我的预订",
+		"menu.customer.password":    "修改密码",
+		"menu.customer.cancel":      "取消预订",
+		"menu.customer.statement":   "查看账单流水",
+		"menu.customer.room_detail": "查看房间详情",
+		"menu.customer.points":      "查看积分",
+		"menu.customer.modify":      "修改预订",
+		"menu.customer.close":       "注销账户（永久停用）",
+		"menu.customer.cart":        "多房间下单（购物车）",
+		"menu.customer.tags":        "按标签筛选",
+		"menu.customer.favorites":   "我的收藏",
+		"menu.customer.messages":    "我的消息（%d 条未读）",
+		"menu.customer.transfer":    "余额转账",
+		"menu.customer.review":      "评价房间",
+		"menu.customer.recommend":   "为你推荐",
+		"menu.back":                 "返回上一层",
+		"menu.logout":               "退出",
+		"prompt.choice":             "请选择操作：",
+		"prompt.username":           "请输入用户名或用户ID：",
+		"prompt.password":           "请输入密码：",
+		"prompt.language":           "请选择语言：",
+		"msg.invalid_choice":        "无效的选项，请重试。",
+		"msg.login_success":         "登录成功！",
+		"msg.login_failed":          "用户名或密码错误！",
+		"msg.logout":                "注销成功",
+		"msg.exit":                  "退出系统",
+		"msg.language_set":          "界面语言已切换为中文",
+		"msg.balance":               "当前余额: %.2f",
+		"msg.dynamic_toggled":       "动态定价已%s：剩余比例低于 %.0f%% 时价格上浮 %.0f%%",
+		"label.on":                  "开启",
+		"label.off":                 "关闭",
+	},
+	"en": {
+		"menu.main":                 "Welcome to the Hotel Management System",
+		"menu.main.login":           "Log in",
+		"menu.main.register":        "Register (customers only)",
+		"menu.main.language":        "切换语言 / Language",
+		"menu.main.exit":            "Exit",
+		"menu.admin":                "Administrator menu",
+		"menu.admin.users":          "User management",
+		"menu.admin.rooms":          "Room management",
+		"menu.admin.bookings":       "Booking management",
+		"menu.admin.statistics":     "Statistics",
+		"menu.admin.export":         "Export data",
+		"menu.admin.transactions":   "Transactions",
+		"menu.admin.settings":       "System settings",
+		"menu.admin.backup":         "Backup and restore",
+		"menu.admin.reviews":        "Reviews",
+		"menu.backup":               "--------- Backup and restore ---------",
+		"menu.backup.create":        "Back up all data",
+		"menu.backup.restore":       "Restore from a backup",
+		"menu.stats":                "--------- Statistics ---------",
+		"menu.stats.overview":       "Revenue and occupancy overview",
+		"menu.stats.top_spenders":   "Top spending customers",
+		"menu.stats.revenue_range":  "Revenue by date range",
+		"menu.stats.user_growth":    "User growth",
+		"menu.settings":             "--------- System settings ---------",
+		"menu.settings.low_balance": "Low balance threshold (currently: %s)",
+		"menu.settings.refund":      "Refund policy (currently: %s)",
+		"menu.settings.coupons":     "Coupons",
+		"menu.settings.surcharge":   "Weekend/holiday surcharge (currently: %s)",
+		"menu.settings.heat":        "Popularity thresholds (currently: %s)",
+		"menu.users":                "--------- User management ---------",
+		"menu.users.list":           "List all users",
+		"menu.users.add":            "Add user",
+		"menu.users.update":         "Update user",
+		"menu.users.delete":         "Delete user",
+		"menu.users.reset_password": "Reset customer password",
+		"menu.users.toggle_deleted": "Show/hide deleted users",
+		"menu.users.adjust_balance": "Adjust customer balance",
+		"menu.users.search":         "Search users",
+		"menu.rooms":                "--------- Room management ---------",
+		"menu.rooms.list":           "List all rooms",
+		"menu.rooms.add":            "Add room",
+		"menu.rooms.update":         "Update room",
+		"menu.rooms.delete":         "Delete room",
+		"menu.rooms.search":         "Search rooms",
+		"menu.rooms.dynamic":        "Toggle dynamic pricing (currently: %s)",
+		"menu.rooms.import":         "Import rooms from file",
+		"menu.rooms.types":          "Manage room type dictionary",
+		"menu.rooms.calendar":       "Room availability calendar",
+		"menu.rooms.toggle":         "Enable/disable a room",
+		"menu.rooms.price_history":  "View price change history",
+		"menu.rooms.bulk_price":     "Bulk price adjustment",
+		"menu.rooms.undo":           "Undo last change",
+		"menu.rooms.maintenance":    "Maintenance windows",
+		"menu.rooms.units":          "Room numbers",
+		"menu.types":                "--------- Room type dictionary ---------",
+		"menu.types.list":           "List standard room types",
+		"menu.types.add":            "Add standard room type",
+		"menu.types.alias":          "Add alias to a room type",
+		"menu.types.delete":         "Delete standard room type",
+		"menu.bookings":             "--------- Booking management ---------",
+		"menu.bookings.all":         "List all bookings",
+		"menu.bookings.by_user":     "Filter by user ID",
+		"menu.bookings.by_room":     "Filter by room ID",
+		"menu.bookings.by_status":   "Filter by status",
+		"menu.bookings.check_in":    "Check in",
+		"menu.bookings.check_out":   "Check out",
+		"menu.bookings.in_house":    "In-house guests",
+		"menu.bookings.units":       "Assign room numbers",
+		"menu.bookings.cancel":      "Cancel booking",
+		"menu.customer":             "Customer menu",
+		"menu.customer.rooms":       "View rooms",
+		"menu.customer.book":        "Book a room",
+		"menu.customer.balance":     "View balance",
+		"menu.customer.recharge":    "Recharge",
+		"menu.customer.search":      "Search rooms",
+		"menu.customer.filter":      "Filter by price",
+		"menu.customer.bookings":    "My bookings",
+		"menu.customer.password":    "Change password",
+		"menu.customer.cancel":      "Cancel a booking",
+		"menu.customer.statement":   "View statement",
+		"menu.customer.room_detail": "View room details",
+		"menu.customer.points":      "View points",
+		"menu.customer.modify":      "Modify a booking",
+		"menu.customer.close":       "Close my account",
+		"menu.customer.cart":        "Book several rooms (cart)",
+		"menu.customer.tags":        "Filter by tags",
+		"menu.customer.favorites":   "My favorites",
+		"menu.customer.messages":    "My messages (%d unread)",
+		"menu.customer.transfer":    "Transfer balance",
+		"menu.customer.review":      "Review a room",
+		"menu.customer.recommend":   "Recommended for you",
+		"menu.back":                 "Back",
+		"menu.logout":               "Log out",
+		"prompt.choice":             "Please choose: ",
+		"prompt.username":           "Username or user ID: ",
+		"prompt.password":           "Password: ",
+		"prompt.language":           "Choose a language: ",
+		"msg.invalid_choice":        "Invalid option, please try again.",
+		"msg.login_success":         "Logged in successfully!",
+		"msg.login_failed":          "Incorrect username or password!",
+		"msg.logout":                "Logged out",
+		"msg.exit":                  "Goodbye",
+		"msg.language_set":          "Language switched to English",
+		"msg.balance":               "Current balance: %.2f",
+		"msg.dynamic_toggled":       "Dynamic pricing %s: when less than %.0f%% of rooms remain, prices rise by %.0f%%",
+		"label.on":                  "on",
+		"label.off":                 "off",
+	},
+}
+
+// currentLanguage 返回当前界面语言，未设置或不支持时使用 defaultLanguage
+func currentLanguage() string {
+	if _, ok := messages[settings.Language]; ok {
+		return settings.Language
+	}
+	return defaultLanguage
+}
+
+// t 返回键在当前语言下的文案；缺失时回退到默认语言，仍缺失则返回键本身
+func t(key string) string {
+	if msg, ok := messages[currentLanguage()][key]; ok {
+		return msg
+	}
+	if msg, ok := messages[defaultLanguage][key]; ok {
+		return msg
+	}
+	return key
+}
+
+// printOptions 按 1. 2. 3. 的编号依次打印菜单选项
+func printOptions(options ...string) {
+	for i, option := range options {
+		fmt.Printf("%d. %s\n", i+1, option)
+	}
+}
+
+// chooseLanguage 让用户选择界面语言并保存到设置
+func chooseLanguage() {
+	names := make([]string, len(languages))
+	for i, lang := range languages {
+		names[i] = lang.Name
+	}
+	printOptions(names...)
+	fmt.Print(t("prompt.language"))
+	index, err := strconv.Atoi(readLine())
+	if err != nil || index < 1 || index > len(languages) {
+		fmt.Println(t("msg.invalid_choice"))
+		return
+	}
+	settings.Language = languages[index-1].Code
+	saveSettings()
+	fmt.Println(t("msg.language_set"))
+}
+
+// ------------------------- 登录与注册 ----------------------------
+
+// login 实现用户登录，输入用户名和密码后返回对应的用户指针（成功则返回，不成功返回 nil）
+func login() *User {
+	fmt.Print(t("prompt.username"))
+	account := readLine()
+	user := findLoginUser(account)
+	// 锁定计数按账号的用户名记录，用用户名或 ID 登录共用同一个计数
+	username := account
+	if user != nil {
+		username = user.Username
+	}
+	if remaining := loginLockRemaining(username); remaining > 0 {
+		fmt.Printf("该账号登录失败次数过多，已被锁定，请在 %d 秒后重试。\n", int(remaining.Seconds())+1)
+		logOperation(username, "登录", "失败：账号已锁定")
+		return nil
+	}
+	fmt.Print(t("prompt.password"))
+	password := readPassword()
+
+	if user != nil && checkPassword(user.Password, password) {
+		delete(loginFailures, username)
+		fmt.Println(t("msg.login_success"))
+		logOperation(username, "登录", "成功")
+		return user
+	}
+	if recordLoginFailure(username) {
+		fmt.Printf("连续登录失败 %d 次，账号已锁定 %d 分钟。\n", maxLoginFailures, int(loginLockDuration.Minutes()))
+		logOperation(username, "登录", "失败：连续失败次数过多，账号被锁定")
+		return nil
+	}
+	fmt.Println(t("msg.login_failed"))
+	logOperation(username, "登录", "失败：用户名或密码错误")
+	return nil
+}
+
+// findLoginUser 按登录输入查找未删除的用户：优先按用户名匹配，
+// 没有同名用户且输入为数字时再按用户 ID 匹配，避免纯数字用户名产生歧义
+func findLoginUser(account string) *User {
+	for i := range users {
+		if !users[i].Deleted && users[i].Username == account {
+			return &users[i]
+		}
+	}
+	if id, err := strconv.Atoi(account); err == nil {
+		return findActiveUserByID(id)
+	}
+	return nil
+}
+
+// loginAttempt 记录某个用户名的连续登录失败次数和锁定截止时间
+type loginAttempt struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+// maxLoginFailures 为连续登录失败的上限，达到后锁定 loginLockDuration
+const maxLoginFailures = 5
+const loginLockDuration = 5 * time.Minute
+
+// loginFailures 按用户名在内存中跟踪登录失败情况
+var loginFailures = make(map[string]*loginAttempt)
+
+// loginLockRemaining 返回用户名剩余的锁定时长，未锁定时返回 0；锁定到期后自动解除
+func loginLockRemaining(username string) time.Duration {
+	attempt, ok := loginFailures[username]
+	if !ok || attempt.lockedUntil.IsZero() {
+		return 0
+	}
+	remaining := time.Until(attempt.lockedUntil)
+	if remaining <= 0 {
+		delete(loginFailures, username)
+		return 0
+	}
+	return remaining
+}
+
+// recordLoginFailure 记录一次登录失败，达到上限时锁定该用户名并返回 true
+func recordLoginFailure(username string) bool {
+	attempt, ok := loginFailures[username]
+	if !ok {
+		attempt = &loginAttempt{}
+		loginFailures[username] = attempt
+	}
+	attempt.failures++
+	if attempt.failures >= maxLoginFailures {
+		attempt.failures = 0
+		attempt.lockedUntil = time.Now().Add(loginLockDuration)
+		return true
+	}
+	return false
+}
+
+// registerCustomer 仅允许注册顾客账号（会员或普通），默认初始余额 1000 元
+func registerCustomer() {
+	fmt.Println("注册新顾客账号")
+	fmt.Print("请输入用户名：")
+	username := readLine()
+	if err := validateUsername(username); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 检查用户名是否已存在
+	if usernameExists(username) {
+		fmt.Println("用户名已存在！")
+		return
+	}
+	fmt.Print("请输入密码：")
+	password := readPassword()
+	if err := validatePassword(password); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print("请选择顾客类型（1. 会员账号 2. 普通账号）：")
+	choice := readLine()
+	var customerType string
+	if choice == "1" {
+		customerType = "member"
+	} else {
+		customerType = "regular"
+	}
+	newUser := User{
+		ID:           getNextUserID(),
+		Username:     username,
+		Password:     hashPassword(password),
+		Role:         "customer",
+		CustomerType: customerType,
+		Balance:      1000.0,
+		CreatedAt:    time.Now().Format(timeLayout),
+	}
+	users = append(users, newUser)
+	saveUsers()
+	recordTransaction(newUser.ID, newUser.Balance, transactionTypeInitial, "注册赠送初始余额")
+	logOperation(username, "注册顾客账号", fmt.Sprintf("成功，用户ID %d", newUser.ID))
+	fmt.Println("注册成功！初始余额为 1000 元。")
+}
+
+// getNextUserID 获取下一个用户 ID（自动递增）
+func getNextUserID() int {
+	maxID := 0
+	for _, user := range users {
+		if user.ID > maxID {
+			maxID = user.ID
+		}
+	}
+	return maxID + 1
+}
+
+// getNextRoomID 获取下一个房间 ID（自动递增）
+func getNextRoomID() int {
+	maxID := 0
+	for _, room := range rooms {
+		if room.ID > maxID {
+			maxID = room.ID
+		}
+	}
+	return maxID + 1
+}
+
+// orderNoDateLayout 为订单号中的日期部分格式，orderNoSeqDigits 为序号的最少位数
+const orderNoDateLayout = "20060102"
+const orderNoSeqDigits = 4
+
+// nextOrderNo 生成 now 当天的下一个订单号，格式为 "日期-序号"，如 20240601-0001。
+// 序号取 list 中同一天订单号的最大序号加 1，因此每天从 0001 重新开始，当天内不重复；
+// 超过 9999 时位数自动增加。调用方需持有 dataMu，保证并发下单时不会生成相同的订单号
+func nextOrderNo(list []Booking, now time.Time) string {
+	prefix := now.Format(orderNoDateLayout) + "-"
+	maxSeq := 0
+	for _, booking := range list {
+		if !strings.HasPrefix(booking.OrderNo, prefix) {
+			continue
+		}
+		if seq, err := strconv.Atoi(strings.TrimPrefix(booking.OrderNo, prefix)); err == nil && seq > maxSeq {
+			maxSeq = seq
+		}
+	}
+	return fmt.Sprintf("%s%0*d", prefix, orderNoSeqDigits, maxSeq+1)
+}
+
+// bookingNo 返回订单对外展示的订单号，旧订单没有 OrderNo 时使用数字 ID
+func bookingNo(booking Booking) string {
+	if booking.OrderNo != "" {
+		return booking.OrderNo
+	}
+	return strconv.Itoa(booking.ID)
+}
+
+// getNextBookingID 获取下一个预订 ID（自动递增）
+func getNextBookingID() int {
+	maxID := 0
+	for _, booking := range bookings {
+		if booking.ID > maxID {
+			maxID = booking.ID
+		}
+	}
+	return maxID + 1
+}
+
+// ------------------------- 管理员功能 ----------------------------
+
+// adminMenu 为管理员提供用户管理、房间管理和预订管理的菜单
+func adminMenu(user *User) {
+	for {
+		fmt.Println("================================")
+		printDashboard()
+		fmt.Println(t("menu.admin"))
+		printOptions(t("menu.admin.users"), t("menu.admin.rooms"), t("menu.admin.bookings"), t("menu.admin.statistics"),
+			t("menu.admin.export"), t("menu.admin.transactions"), t("menu.admin.settings"),
+			t("menu.admin.backup"), t("menu.admin.reviews"), t("menu.logout"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			adminUserManagement(user)
+		case "2":
+			adminRoomManagement(user)
+		case "3":
+			adminBookingManagement()
+		case "4":
+			adminStatistics()
+		case "5":
+			exportData()
+		case "6":
+			printTransactions(transactions)
+		case "7":
+			adminSettings()
+		case "8":
+			// 恢复备份后内存中的用户数据已重新加载，当前登录信息失效，需要重新登录
+			if backupMenu(user) {
+				return
+			}
+		case "9":
+			adminReviewManagement()
+		case "10":
+			fmt.Println(t("msg.logout"))
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// maxPasswordConfirmAttempts 为敏感操作确认管理员密码时允许输错的次数
+const maxPasswordConfirmAttempts = 3
+
+// confirmAdminPassword 在执行删除、批量导入等敏感操作前要求管理员再次输入密码；
+// 连续输错 maxPasswordConfirmAttempts 次则取消操作并记录日志
+func confirmAdminPassword(admin *User, action string) bool {
+	for i := 1; i <= maxPasswordConfirmAttempts; i++ {
+		fmt.Print("该操作需要验证身份，请输入当前管理员密码：")
+		if checkPassword(admin.Password, readPassword()) {
+			return true
+		}
+		if i < maxPasswordConfirmAttempts {
+			fmt.Printf("密码错误，还可尝试 %d 次\n", maxPasswordConfirmAttempts-i)
+		}
+	}
+	fmt.Println("密码错误次数过多，操作已取消")
+	logOperation(operatorName(), action, fmt.Sprintf("失败：连续 %d 次管理员密码验证失败，操作已取消", maxPasswordConfirmAttempts))
+	return false
+}
+
+// adminSettings 管理员修改系统设置
+func adminSettings() {
+	for {
+		fmt.Println(t("menu.settings"))
+		printOptions(fmt.Sprintf(t("menu.settings.low_balance"), lowBalanceThresholdLabel()),
+			fmt.Sprintf(t("menu.settings.refund"), refundPolicyLabel(refundPolicy())), t("menu.settings.coupons"),
+			fmt.Sprintf(t("menu.settings.surcharge"), surchargeLabel(settings)),
+			fmt.Sprintf(t("menu.settings.heat"), heatThresholdLabel()), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			fmt.Print("请输入新的余额提醒阈值（输入 0 表示按最便宜房间价格）：")
+			threshold, err := strconv.ParseFloat(readLine(), 64)
+			if err != nil || math.IsNaN(threshold) || math.IsInf(threshold, 0) || threshold < 0 {
+				fmt.Println("无效的金额")
+				continue
+			}
+			settings.LowBalanceThreshold = threshold
+			saveSettings()
+			fmt.Printf("余额提醒阈值已设置为: %s\n", lowBalanceThresholdLabel())
+		case "2":
+			fmt.Print("请输入退款策略，格式为 天数:比例 并用逗号分隔（如 3:1,1:0.5,0:0；输入 default 恢复默认）：")
+			input := readLine()
+			if input == "default" {
+				settings.RefundPolicy = nil
+			} else {
+				policy, err := parseRefundPolicy(input)
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+				settings.RefundPolicy = policy
+			}
+			saveSettings()
+			logOperation(operatorName(), "修改退款策略", "成功")
+			fmt.Printf("退款策略已设置为: %s\n", refundPolicyLabel(refundPolicy()))
+		case "3":
+			couponMenu()
+		case "4":
+			surchargeMenu()
+		case "5":
+			fmt.Print("请输入标记热门所需的 24 小时订出间数（输入 0 恢复默认）：")
+			hot, err := strconv.Atoi(readLine())
+			if err != nil || hot < 0 {
+				fmt.Println("无效的数量")
+				continue
+			}
+			fmt.Print("请输入提示“手慢无”的剩余间数（输入 0 恢复默认）：")
+			low, err := strconv.Atoi(readLine())
+			if err != nil || low < 0 {
+				fmt.Println("无效的数量")
+				continue
+			}
+			settings.HotBookedThreshold, settings.LowStockThreshold = hot, low
+			saveSettings()
+			logOperation(operatorName(), "修改热度提醒阈值", "成功")
+			fmt.Printf("热度提醒阈值已设置为: %s\n", heatThresholdLabel())
+		case "6":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// adminUserManagement 实现管理员对用户的增删改查操作，current 为当前登录的管理员
+func adminUserManagement(current *User) {
+	for {
+		fmt.Println(t("menu.users"))
+		printOptions(t("menu.users.list"), t("menu.users.add"), t("menu.users.update"), t("menu.users.delete"),
+			t("menu.users.reset_password"), t("menu.users.toggle_deleted"), t("menu.users.adjust_balance"), t("menu.users.search"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			listUsers()
+		case "2":
+			addUser()
+		case "3":
+			updateUser()
+		case "4":
+			deleteUser(current)
+		case "5":
+			resetPassword()
+		case "6":
+			toggleShowDeletedUsers()
+		case "7":
+			adjustUserBalance()
+		case "8":
+			searchUsers()
+		case "9":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// listUsers 显示所有用户信息
+func listUsers() {
+	var list []User
+	for _, user := range users {
+		if showDeletedUsers || !user.Deleted {
+			list = append(list, user)
+		}
+	}
+	if showDeletedUsers {
+		fmt.Println("----- 所有用户列表（含已删除） -----")
+	} else {
+		fmt.Println("----- 所有用户列表 -----")
+	}
+	printPaged(len(list), func(i int) {
+		printUser(list[i])
+	})
+}
+
+// userFilter 为用户搜索条件，各字段为空表示不限，多个条件之间为“且”的关系
+type userFilter struct {
+	Keyword      string // 用户名关键字，部分匹配，忽略大小写和首尾空白
+	Role         string // "admin" 或 "customer"
+	CustomerType string // "member" 或 "regular"，指定时只匹配顾客
+}
+
+// filterUsers 返回 list 中满足 filter 全部条件的用户；includeDeleted 为 false 时排除已删除用户
+func filterUsers(list []User, filter userFilter, includeDeleted bool) []User {
+	keyword := strings.ToLower(strings.TrimSpace(filter.Keyword))
+	var result []User
+	for _, user := range list {
+		if user.Deleted && !includeDeleted {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(user.Username), keyword) {
+			continue
+		}
+		if filter.Role != "" && user.Role != filter.Role {
+			continue
+		}
+		if filter.CustomerType != "" && (user.Role != "customer" || user.CustomerType != filter.CustomerType) {
+			continue
+		}
+		result = append(result, user)
+	}
+	return result
+}
+
+// searchUsers 交互式地输入用户名关键字、角色、顾客类型并显示匹配的用户，
+// 是否包含已删除用户与用户列表的显示设置一致
+func searchUsers() {
+	var filter userFilter
+	fmt.Print("请输入用户名关键字（直接回车表示不限）：")
+	filter.Keyword = readLine()
+	fmt.Print("请选择角色（1. 管理员 2. 顾客，直接回车表示不限）：")
+	switch readLine() {
+	case "":
+	case "1":
+		filter.Role = "admin"
+	case "2":
+		filter.Role = "customer"
+	default:
+		fmt.Println("无效的角色选项")
+		return
+	}
+	if filter.Role != "admin" {
+		fmt.Print("请选择顾客类型（1. 会员账号 2. 普通账号，直接回车表示不限）：")
+		switch readLine() {
+		case "":
+		case "1":
+			filter.CustomerType = "member"
+		case "2":
+			filter.CustomerType = "regular"
+		default:
+			fmt.Println("无效的顾客类型选项")
+			return
+		}
+	}
+	result := filterUsers(users, filter, showDeletedUsers)
+	if len(result) == 0 {
+		fmt.Println("未找到符合条件的用户，请放宽搜索条件后重试")
+		return
+	}
+	fmt.Printf("----- 搜索结果（共 %d 个用户） -----\n", len(result))
+	printPaged(len(result), func(i int) {
+		printUser(result[i])
+	})
+}
+
+// showDeletedUsers 控制用户列表是否显示已软删除的用户
+var showDeletedUsers bool
+
+// toggleShowDeletedUsers 切换用户列表是否显示已删除用户
+func toggleShowDeletedUsers() {
+	showDeletedUsers = !showDeletedUsers
+	if showDeletedUsers {
+		fmt.Println("用户列表将显示已删除用户")
+	} else {
+		fmt.Println("用户列表将隐藏已删除用户")
+	}
+}
+
+// printUser 打印一行用户信息
+func printUser(user User) {
+	fmt.Printf("ID: %d, 用户名: %s, 角色: %s", user.ID, user.Username, user.Role)
+	if user.Role == "customer" {
+		fmt.Printf(", 类型: %s, 余额: %.2f, 积分: %d", user.CustomerType, user.Balance, user.Points)
+	}
+	if user.Deleted {
+		fmt.Print(" [已删除]")
+	}
+	fmt.Println()
+}
+
+// pageSize 为分页显示时每页的条数
+const pageSize = 10
+
+// printPaged 分页打印 total 条记录，printItem 负责打印第 i 条。
+// 记录不超过一页时直接全部打印；否则用户可输入 n/p 翻页、q 退出
+func printPaged(total int, printItem func(i int)) {
+	if total == 0 {
+		fmt.Println("（列表为空）")
+		return
+	}
+	pages := (total + pageSize - 1) / pageSize
+	page := 0
+	show := true
+	for {
+		if show {
+			start := page * pageSize
+			end := start + pageSize
+			if end > total {
+				end = total
+			}
+			for i := start; i < end; i++ {
+				printItem(i)
+			}
+		}
+		if pages == 1 {
+			return
+		}
+		fmt.Printf("—— 第 %d/%d 页，共 %d 条 ——\n", page+1, pages, total)
+		fmt.Print("输入 n 下一页，p 上一页，q 退出：")
+		show = false
+		switch strings.ToLower(readLine()) {
+		case "n":
+			if page == pages-1 {
+				fmt.Println("已经是最后一页")
+			} else {
+				page++
+				show = true
+			}
+		case "p":
+			if page == 0 {
+				fmt.Println("已经是第一页")
+			} else {
+				page--
+				show = true
+			}
+		case "q":
+			return
+		default:
+			fmt.Println("无效的输入")
+		}
+	}
+}
+
+// addUser 由管理员添加新用户，可以添加管理员或顾客账号
+func addUser() {
+	fmt.Println("----- 添加新用户 -----")
+	fmt.Print("请输入用户名：")
+	username := readLine()
+	if err := validateUsername(username); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 检查用户名是否已存在
+	if usernameExists(username) {
+		fmt.Println("用户名已存在！")
+		return
+	}
+	fmt.Print("请输入密码：")
+	password := readPassword()
+	if err := validatePassword(password); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print("请选择角色（1. 管理员 2. 顾客）：")
+	roleChoice := readLine()
+	var role string
+	var customerType string
+	var balance float64
+	if roleChoice == "1" {
+		role = "admin"
+	} else if roleChoice == "2" {
+		role = "customer"
+		fmt.Print("请选择顾客类型（1. 会员账号 2. 普通账号）：")
+		ctChoice := readLine()
+		if ctChoice == "1" {
+			customerType = "member"
+		} else {
+			customerType = "regular"
+		}
+		balance = 1000.0 // 初始余额
+	} else {
+		fmt.Println("无效的角色选项")
+		return
+	}
+	newUser := User{
+		ID:           getNextUserID(),
+		Username:     username,
+		Password:     hashPassword(password),
+		Role:         role,
+		CustomerType: customerType,
+		Balance:      balance,
+		CreatedAt:    time.Now().Format(timeLayout),
+	}
+	users = append(users, newUser)
+	saveUsers()
+	if role == "customer" {
+		recordTransaction(newUser.ID, newUser.Balance, transactionTypeInitial, "管理员添加账号的初始余额")
+	}
+	logOperation(operatorName(), fmt.Sprintf("添加用户 %s（ID %d，角色 %s）", username, newUser.ID, role), "成功")
+	fmt.Println("用户添加成功！")
+}
+
+// updateUser 修改指定用户的信息
+func updateUser() {
+	fmt.Print("请输入要修改的用户ID：")
+	idStr := readLine()
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	user := findActiveUserByID(id)
+	if user == nil {
+		fmt.Println("未找到该用户")
+		return
+	}
+	fmt.Printf("当前用户名: %s\n", user.Username)
+	fmt.Print("请输入新的用户名（直接回车保持不变）：")
+	newUsername := readLine()
+	if newUsername != "" && newUsername != user.Username {
+		if err := validateUsername(newUsername); err != nil {
+			fmt.Printf("%v，用户名保持不变\n", err)
+		} else if usernameExists(newUsername) {
+			fmt.Println("用户名已存在，用户名保持不变")
+		} else {
+			user.Username = newUsername
+		}
+	}
+	fmt.Print("请输入新的密码（直接回车保持不变）：")
+	newPassword := readPassword()
+	if newPassword != "" {
+		if err := validatePassword(newPassword); err != nil {
+			fmt.Printf("%v，密码保持不变\n", err)
+		} else {
+			user.Password = hashPassword(newPassword)
+		}
+	}
+	// 如果是顾客，则可修改顾客类型和余额
+	if user.Role == "customer" {
+		fmt.Printf("当前顾客类型: %s\n", user.CustomerType)
+		fmt.Print("请选择新的顾客类型（1. 会员账号 2. 普通账号，回车保持不变）：")
+		ctChoice := readLine()
+		if ctChoice == "1" {
+			user.CustomerType = "member"
+		} else if ctChoice == "2" {
+			user.CustomerType = "regular"
+		}
+		fmt.Printf("当前余额: %.2f\n", user.Balance)
+		fmt.Print("请输入新的余额（回车保持不变）：")
+		balanceStr := readLine()
+		if balanceStr != "" {
+			b, err := strconv.ParseFloat(balanceStr, 64)
+			if err == nil {
+				if delta := b - user.Balance; delta != 0 {
+					if err := adjustBalance(user, delta); err != nil {
+						fmt.Printf("%v，余额保持不变\n", err)
+					} else {
+						recordTransaction(user.ID, delta, transactionTypeAdjust, "管理员修改用户信息时调整余额")
+					}
+				}
+			} else {
+				fmt.Println("无效的余额输入")
+			}
+		}
+	}
+	saveUsers()
+	logOperation(operatorName(), fmt.Sprintf("修改用户 %d", user.ID), "成功")
+	fmt.Println("用户信息更新成功")
+}
+
+// resetPassword 管理员为忘记密码的顾客设置新密码，或生成随机临时密码
+func resetPassword() {
+	fmt.Print("请输入要重置密码的用户ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	user := findActiveUserByID(id)
+	if user == nil {
+		fmt.Println("未找到该用户")
+		return
+	}
+	if user.Role != "customer" {
+		fmt.Println("只能重置顾客账号的密码")
+		return
+	}
+	fmt.Print("请选择（1. 手动设置新密码 2. 生成随机临时密码）：")
+	var password string
+	switch readLine() {
+	case "1":
+		fmt.Print("请输入新密码：")
+		password = readPassword()
+		if err := validatePassword(password); err != nil {
+			fmt.Println(err)
+			return
+		}
+	case "2":
+		password = generateTempPassword()
+		fmt.Printf("临时密码: %s（请告知顾客并提醒其登录后尽快修改）\n", password)
+	default:
+		fmt.Println("无效的选项")
+		return
+	}
+	user.Password = hashPassword(password)
+	saveUsers()
+	logOperation(operatorName(), fmt.Sprintf("重置用户 %d 的密码", user.ID), "成功")
+	fmt.Printf("用户 %s 的密码已重置\n", user.Username)
+}
+
+// generateTempPassword 生成满足密码强度规则的随机临时密码
+func generateTempPassword() string {
+	// 去掉了容易混淆的字符 l、o、I、O、0、1
+	const charset = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	const length = 10
+	for {
+		buf := make([]byte, length)
+		for i := range buf {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+			if err != nil {
+				fmt.Println("生成随机密码错误：", err)
+				os.Exit(1)
+			}
+			buf[i] = charset[n.Int64()]
+		}
+		if password := string(buf); validatePassword(password) == nil {
+			return password
+		}
+	}
+}
+
+// deleteUser 软删除指定用户（管理员操作），不允许删除当前登录的账号和唯一的管理员。
+// 被删除的用户只是打上 Deleted 标记，记录仍保留用于审计和历史预订关联
+func deleteUser(current *User) {
+	fmt.Print("请输入要删除的用户ID：")
+	idStr := readLine()
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	user := findActiveUserByID(id)
+	if user == nil {
+		fmt.Println("未找到该用户")
+		return
+	}
+	if user.ID == current.ID {
+		fmt.Println("不能删除当前登录的管理员账号")
+		return
+	}
+	if isLastAdmin(user) {
+		fmt.Println("系统至少需要保留一个管理员，无法删除唯一的管理员账号")
+		return
+	}
+	active := activeBookingsForUser(id)
+	if n := checkedInCount(active); n > 0 {
+		fmt.Printf("该用户有 %d 个已入住的订单，请先办理退房后再删除\n", n)
+		return
+	}
+	if len(active) > 0 {
+		fmt.Printf("该用户还有 %d 个未取消的预订：\n", len(active))
+		printBookingPointers(active)
+		fmt.Print("请选择（1. 连同退订这些预订并释放库存 其它. 取消删除）：")
+		if readLine() != "1" {
+			fmt.Println("已取消删除")
+			return
+		}
+	}
+	fmt.Print("确定要删除该用户吗？(y/n): ")
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		return
+	}
+	if !confirmAdminPassword(current, fmt.Sprintf("删除用户 %d", id)) {
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	// 先退订再删除用户，退款仍记入该用户余额并随记录一起保留
+	for _, booking := range active {
+		cancelBooking(booking, "账户已被删除")
+	}
+	user.Deleted = true
+	saveUsers()
+	if len(active) > 0 {
+		saveRooms()
+		saveBookings()
+		fmt.Printf("已退订 %d 个预订并释放房间库存\n", len(active))
+	}
+	logOperation(operatorName(), fmt.Sprintf("删除用户 %d", user.ID), fmt.Sprintf("成功，退订 %d 个预订", len(active)))
+	fmt.Println("用户删除成功")
+}
+
+// isLastAdmin 判断用户是否为系统中唯一的管理员。删除用户或把管理员改为其它角色前
+// 都必须先通过该检查，否则系统将无人能进入管理功能
+func isLastAdmin(user *User) bool {
+	return user.Role == "admin" && countAdmins() <= 1
+}
+
+// countAdmins 统计系统中管理员账号的数量
+func countAdmins() int {
+	count := 0
+	for _, user := range users {
+		if user.Role == "admin" && !user.Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// activeBookingsForUser 返回该用户所有仍占用库存的预订（已预订或已入住）的指针
+func activeBookingsForUser(userID int) []*Booking {
+	var result []*Booking
+	for i := range bookings {
+		if bookings[i].UserID == userID && bookingHoldsRoom(bookings[i]) {
+			result = append(result, &bookings[i])
+		}
+	}
+	return result
+}
+
+// adminRoomManagement 管理员对房间的增删改查操作，current 为当前登录的管理员
+func adminRoomManagement(current *User) {
+	for {
+		fmt.Println(t("menu.rooms"))
+		printOptions(t("menu.rooms.list"), t("menu.rooms.add"), t("menu.rooms.update"), t("menu.rooms.delete"),
+			t("menu.rooms.search"), fmt.Sprintf(t("menu.rooms.dynamic"), onOffLabel(settings.DynamicPricing)),
+			t("menu.rooms.import"), t("menu.rooms.types"), t("menu.rooms.calendar"),
+			t("menu.rooms.toggle"), t("menu.rooms.price_history"), t("menu.rooms.bulk_price"),
+			t("menu.rooms.undo"), t("menu.rooms.maintenance"), t("menu.rooms.units"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			listRooms()
+		case "2":
+			addRoom()
+		case "3":
+			updateRoom()
+		case "4":
+			deleteRoom(current)
+		case "5":
+			searchRooms()
+		case "6":
+			toggleDynamicPricing()
+		case "7":
+			importRoomsMenu(current)
+		case "8":
+			manageRoomTypes()
+		case "9":
+			showRoomCalendar()
+		case "10":
+			toggleRoomEnabled()
+		case "11":
+			showPriceHistory()
+		case "12":
+			bulkAdjustPrices()
+		case "13":
+			undoLastMenu()
+		case "14":
+			maintenanceMenu()
+		case "15":
+			roomUnitMenu()
+		case "16":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// onOffLabel 把开关状态显示为"开启"或"关闭"
+func onOffLabel(on bool) string {
+	if on {
+		return t("label.on")
+	}
+	return t("label.off")
+}
+
+// toggleDynamicPricing 开启或关闭动态定价并立即保存设置
+func toggleDynamicPricing() {
+	settings.DynamicPricing = !settings.DynamicPricing
+	saveSettings()
+	fmt.Printf(t("msg.dynamic_toggled")+"\n", onOffLabel(settings.DynamicPricing), lowStockRatio*100, surgeRate*100)
+}
+
+// listRooms 显示所有房间信息
+func listRooms() {
+	if len(rooms) == 0 {
+		fmt.Println("当前无房间信息")
+		return
+	}
+	fmt.Print("是否显示房间详情？(y/n): ")
+	detail := readLine()
+	list := sortRooms(rooms, chooseRoomSort())
+	fmt.Println("----- 房间列表 -----")
+	printPaged(len(list), func(i int) {
+		if detail == "y" || detail == "Y" {
+			printRoomDetail(list[i])
+		} else {
+			printRooms(list[i : i+1])
+		}
+	})
+}
+
+// 房间列表的排序方式
+const (
+	roomSortByID            = "id"
+	roomSortByPriceAsc      = "price_asc"
+	roomSortByPriceDesc     = "price_desc"
+	roomSortByAvailableDesc = "available_desc"
+)
+
+// chooseRoomSort 让用户选择房间列表的排序方式，直接回车或输入无效时按 ID 排序
+func chooseRoomSort() string {
+	fmt.Print("请选择排序方式（1. 按ID 2. 价格从低到高 3. 价格从高到低 4. 剩余数量从多到少，回车默认按ID）：")
+	switch readLine() {
+	case "2":
+		return roomSortByPriceAsc
+	case "3":
+		return roomSortByPriceDesc
+	case "4":
+		return roomSortByAvailableDesc
+	default:
+		return roomSortByID
+	}
+}
+
+// sortRooms 返回按指定方式排序后的房间副本，不改变传入切片的顺序；排序键相同时按 ID 升序
+func sortRooms(list []Room, order string) []Room {
+	sorted := make([]Room, len(list))
+	copy(sorted, list)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch order {
+		case roomSortByPriceAsc:
+			if a.Price != b.Price {
+				return a.Price < b.Price
+			}
+		case roomSortByPriceDesc:
+			if a.Price != b.Price {
+				return a.Price > b.Price
+			}
+		case roomSortByAvailableDesc:
+			if a.Available != b.Available {
+				return a.Available > b.Available
+			}
+		}
+		return a.ID < b.ID
+	})
+	return sorted
+}
+
+// printRoomDetail 打印房间的完整信息，包括描述和设施
+func printRoomDetail(room Room) {
+	fmt.Printf("ID: %d, 类型: %s, 价格: %.2f%s, 总数: %d, 剩余: %d%s\n",
+		room.ID, room.Type, room.Price, priceUnitSuffix(room), room.Total, room.Available, roomStatusSuffix(room))
+	description := room.Description
+	if description == "" {
+		description = "暂无"
+	}
+	fmt.Printf("    标准房型: %s\n", normalizeRoomType(room.Type))
+	fmt.Printf("    可住人数: %s\n", capacityLabel(room))
+	fmt.Printf("    描述: %s\n", description)
+	facilities := "暂无"
+	if len(room.Facilities) > 0 {
+		facilities = strings.Join(room.Facilities, "、")
+	}
+	fmt.Printf("    设施: %s\n", facilities)
+	tags := "暂无"
+	if len(room.Tags) > 0 {
+		tags = strings.Join(room.Tags, "、")
+	}
+	fmt.Printf("    标签: %s\n", tags)
+	if len(room.Maintenance) > 0 {
+		labels := make([]string, len(room.Maintenance))
+		for i, window := range room.Maintenance {
+			labels[i] = maintenanceLabel(window)
+		}
+		fmt.Printf("    维护安排: %s\n", strings.Join(labels, "；"))
+	}
+	if len(room.Units) > 0 {
+		labels := make([]string, len(room.Units))
+		for i, unit := range room.Units {
+			labels[i] = roomUnitLabel(unit)
+		}
+		fmt.Printf("    房间号: %s\n", strings.Join(labels, "、"))
+	}
+}
+
+// parseFacilities 把逗号（中英文均可）或顿号分隔的输入解析为设施列表，忽略空项
+func parseFacilities(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、'
+	})
+	var result []string
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// listAvailableRooms 仅显示仍可预订的房间（过滤掉已订满的房间），供顾客使用
+func listAvailableRooms(order string) {
+	available := availableRooms()
+	if len(available) == 0 {
+		fmt.Println("当前所有房间均已订满，暂无可预订的房间")
+		return
+	}
+	fmt.Println("----- 可预订房间列表 -----")
+	printRooms(sortRooms(available, order))
+}
+
+// availableRooms 返回所有仍可预订的房间
+func availableRooms() []Room {
+	var result []Room
+	for _, room := range rooms {
+		if isRoomBookable(room) {
+			result = append(result, room)
+		}
+	}
+	return result
+}
+
+// isRoomBookable 判断房间当前是否还能被预订：未下架且有剩余
+func isRoomBookable(room Room) bool {
+	return !room.Disabled && room.Available > 0
+}
+
+// printRooms 逐行打印给定的房间列表
+func printRooms(list []Room) {
+	for _, room := range list {
+		tags := ""
+		if len(room.Tags) > 0 {
+			tags = ", 标签: " + strings.Join(room.Tags, "、")
+		}
+		capacity := ""
+		if n := roomCapacity(room); n > 0 {
+			capacity = fmt.Sprintf(", 可住: %d 人", n)
+		}
+		fmt.Printf("ID: %d, 类型: %s, 价格: %.2f%s, 总数: %d, 剩余: %d%s%s%s\n",
+			room.ID, room.Type, room.Price, priceUnitSuffix(room), room.Total, room.Available, capacity, tags, roomStatusSuffix(room))
+	}
+}
+
+// roomStatusSuffix 返回房间状态的附加显示，已下架的房间显示 [已下架]
+func roomStatusSuffix(room Room) string {
+	if room.Disabled {
+		return " [已下架]"
+	}
+	return ""
+}
+
+// searchRoomsByType 按类型关键字筛选房间，支持部分匹配，忽略大小写和首尾空白；
+// 关键字是标准房型或其别名时，同时返回归属该标准房型的房间
+func searchRoomsByType(keyword string) []Room {
+	category := normalizeRoomType(keyword)
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var result []Room
+	for _, room := range rooms {
+		// 关键字能映射到标准房型时按标准房型匹配，否则按房型名称包含关键字匹配
+		if strings.Contains(strings.ToLower(room.Type), keyword) ||
+			(category != otherRoomType && normalizeRoomType(room.Type) == category) {
+			result = append(result, room)
+		}
+	}
+	return result
+}
+
+// searchRooms 交互式地输入关键字并显示匹配的房间
+func searchRooms() {
+	fmt.Print("请输入房间类型关键字：")
+	keyword := readLine()
+	if keyword == "" {
+		fmt.Println("关键字不能为空")
+		return
+	}
+	result := searchRoomsByType(keyword)
+	if len(result) == 0 {
+		fmt.Printf("未找到类型包含“%s”的房间\n", keyword)
+		return
+	}
+	fmt.Println("----- 搜索结果 -----")
+	printRooms(result)
+}
+
+// addRoom 添加新房间（仅管理员操作）
+func addRoom() {
+	fmt.Println("----- 添加新房间 -----")
+	fmt.Print("请输入房间类型：")
+	roomType := readLine()
+	fmt.Printf("对应标准房型: %s\n", normalizeRoomType(roomType))
+	if existing := findRoomByType(roomType, 0); existing != nil {
+		fmt.Printf("房型“%s”已存在（ID: %d, 价格: %.2f, 总数: %d）\n",
+			existing.Type, existing.ID, existing.Price, existing.Total)
+		fmt.Print("请选择（1. 增加已有房型的总数 2. 仍作为新房型添加 其它. 取消）：")
+		switch readLine() {
+		case "1":
+			addToExistingRoom(existing)
+			return
+		case "2":
+		default:
+			fmt.Println("已取消添加")
+			return
+		}
+	}
+	pricingUnit := choosePricingUnit(pricingUnitNight)
+	fmt.Print("请输入房间价格：")
+	priceStr := readLine()
+	price, err := strconv.ParseFloat(priceStr, 64)
+	if err != nil {
+		fmt.Println("无效的价格输入")
+		return
+	}
+	if !checkRoomValue(validateRoomPrice(price)) {
+		return
+	}
+	fmt.Print("请输入房间总数：")
+	totalStr := readLine()
+	total, err := strconv.Atoi(totalStr)
+	if err != nil {
+		fmt.Println("无效的房间数量")
+		return
+	}
+	if !checkRoomValue(validateRoomTotal(total)) {
+		return
+	}
+	fmt.Print("请输入房间描述（可留空）：")
+	description := readLine()
+	fmt.Print("请输入房间设施，用逗号分隔（如 wifi,空调，可留空）：")
+	facilities := parseFacilities(readLine())
+	capacity, ok := readRoomCapacity(roomType)
+	if !ok {
+		return
+	}
+	createRoom(roomType, price, total, description, facilities, pricingUnit, capacity)
+	fmt.Println("房间添加成功！")
+}
+
+// parsePricingUnit 解析计价单位输入，支持 night/stay 和 按晚/按次，空字符串视为按晚
+func parsePricingUnit(input string) (string, error) {
+	switch strings.TrimSpace(input) {
+	case "", pricingUnitNight, "按晚":
+		return pricingUnitNight, nil
+	case pricingUnitStay, "按次":
+		return pricingUnitStay, nil
+	default:
+		return "", fmt.Errorf("无效的计价单位“%s”，可选 night（按晚）或 stay（按次）", input)
+	}
+}
+
+// choosePricingUnit 让管理员选择计价单位，直接回车保持 current
+func choosePricingUnit(current string) string {
+	fmt.Printf("请选择计价方式（1. 按晚 2. 按次，回车为%s）：", pricingUnitLabel(current))
+	switch readLine() {
+	case "1":
+		return pricingUnitNight
+	case "2":
+		return pricingUnitStay
+	default:
+		return current
+	}
+}
+
+// pricingUnitLabel 返回计价单位的中文名称
+func pricingUnitLabel(unit string) string {
+	if unit == pricingUnitStay {
+		return "按次"
+	}
+	return "按晚"
+}
+
+// validateRoomCapacity 校验可住人数：0 表示按房型推断，其余须在 1 到 maxRoomCapacity 之间
+func validateRoomCapacity(capacity int) error {
+	if capacity < 0 || capacity > maxRoomCapacity {
+		return fmt.Errorf("可住人数必须在 0 到 %d 之间（0 表示按房型推断）", maxRoomCapacity)
+	}
+	return nil
+}
+
+// readRoomCapacity 读取新房间的可住人数，直接回车时按房型推断（记为 0）
+func readRoomCapacity(roomType string) (int, bool) {
+	hint := "无法推断"
+	if n := defaultRoomCapacities[normalizeRoomType(roomType)]; n > 0 {
+		hint = fmt.Sprintf("%d 人", n)
+	}
+	fmt.Printf("请输入每间可住人数（直接回车按房型推断：%s）：", hint)
+	input := readLine()
+	if input == "" {
+		return 0, true
+	}
+	capacity, err := strconv.Atoi(input)
+	if err == nil {
+		err = validateRoomCapacity(capacity)
+	}
+	if err != nil {
+		fmt.Println("无效的可住人数")
+		return 0, false
+	}
+	return capacity, true
+}
+
+// roomCapacity 返回房间每间的可住人数：未设置时按标准房型的默认值推断，无法推断时返回 0 表示未知
+func roomCapacity(room Room) int {
+	if room.Capacity > 0 {
+		return room.Capacity
+	}
+	return defaultRoomCapacities[normalizeRoomType(room.Type)]
+}
+
+// capacityLabel 返回可住人数的显示文字，推断得出的值会注明
+func capacityLabel(room Room) string {
+	switch {
+	case room.Capacity > 0:
+		return fmt.Sprintf("%d 人", room.Capacity)
+	case roomCapacity(room) > 0:
+		return fmt.Sprintf("%d 人（按房型推断）", roomCapacity(room))
+	default:
+		return "未知"
+	}
+}
+
+// roomFitsGuests 判断预订 quantity 间该房间能否住下 guests 人；
+// 未填写人数（guests 为 0）或房间容量未知时无法判断，视为可以
+func roomFitsGuests(room Room, guests, quantity int) bool {
+	capacity := roomCapacity(room)
+	if guests <= 0 || capacity == 0 {
+		return true
+	}
+	return capacity*quantity >= guests
+}
+
+// roomsForGuests 返回 list 中单间即可住下 guests 人的可预订房间，容量小的在前，容量相同时价格低的在前；
+// 容量未知的房间不参与推荐
+func roomsForGuests(list []Room, guests int) []Room {
+	var result []Room
+	for _, room := range list {
+		if isRoomBookable(room) && roomCapacity(room) >= guests {
+			result = append(result, room)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ci, cj := roomCapacity(result[i]), roomCapacity(result[j])
+		if ci != cj {
+			return ci < cj
+		}
+		if result[i].Price != result[j].Price {
+			return result[i].Price < result[j].Price
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// priceUnitSuffix 返回价格后显示的单位，如 "/晚"、"/次"
+func priceUnitSuffix(room Room) string {
+	if room.PricingUnit == pricingUnitStay {
+		return "/次"
+	}
+	return "/晚"
+}
+
+// createRoom 用已校验过的数据创建新房间并保存，剩余数量初始化为总数
+func createRoom(roomType string, price float64, total int, description string, facilities []string, pricingUnit string, capacity int) Room {
+	newRoom := Room{
+		ID:          getNextRoomID(),
+		Type:        roomType,
+		Price:       price,
+		Total:       total,
+		Available:   total,
+		Description: description,
+		Facilities:  facilities,
+		PricingUnit: pricingUnit,
+		Capacity:    capacity,
+	}
+	rooms = append(rooms, newRoom)
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("添加房间 %d（%s，价格 %.2f，总数 %d）", newRoom.ID, roomType, price, total), "成功")
+	return newRoom
+}
+
+// 房间价格和数量的告警阈值，超过时需要管理员确认，防止误输入
+const (
+	unusualRoomPrice = 100000.0
+	unusualRoomTotal = 1000
+)
+
+// validateRoomPrice 校验房间价格：必须大于 0；异常大时返回告警信息
+func validateRoomPrice(price float64) (warning string, err error) {
+	if math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+		return "", errors.New("房间价格必须大于 0")
+	}
+	if price > unusualRoomPrice {
+		return fmt.Sprintf("房间价格 %.2f 超过 %.2f，数值异常大", price, unusualRoomPrice), nil
+	}
+	return "", nil
+}
+
+// validateRoomTotal 校验房间总数：不能为负数；异常大时返回告警信息
+func validateRoomTotal(total int) (warning string, err error) {
+	if total < 0 {
+		return "", errors.New("房间总数不能为负数")
+	}
+	if total > unusualRoomTotal {
+		return fmt.Sprintf("房间总数 %d 超过 %d，数值异常大", total, unusualRoomTotal), nil
+	}
+	return "", nil
+}
+
+// validateTotalChange 校验修改后的总数不小于该房间已被预订的数量
+func validateTotalChange(room Room, newTotal int) error {
+	booked := room.Total - room.Available
+	if newTotal < booked {
+		return fmt.Errorf("该房间已预订 %d 间，总数不能小于已预订数量", booked)
+	}
+	if newTotal < len(room.Units) {
+		return fmt.Errorf("该房间已登记 %d 个房间号，总数不能小于房间号数量", len(room.Units))
+	}
+	return nil
+}
+
+// checkRoomValue 处理校验结果：有错误时打印并返回 false；有告警时请管理员确认
+func checkRoomValue(warning string, err error) bool {
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	if warning == "" {
+		return true
+	}
+	fmt.Printf("警告：%s，确定继续吗？(y/n): ", warning)
+	confirm := readLine()
+	return confirm == "y" || confirm == "Y"
+}
+
+// importResult 汇总一次批量导入的结果
+type importResult struct {
+	Imported int
+	Skipped  []string // 被跳过的记录及原因
+	Warnings []string // 已导入但数值异常的记录
+}
+
+// importRooms 从 CSV 或 JSON 文件批量导入房间，按扩展名判断格式：
+//   - CSV 第一行为表头，之后每行依次为 类型,价格,总数,描述,设施,计价单位（后三列可省略，设施用顿号分隔）；
+//   - JSON 为房间对象数组，字段同 rooms.json，其中 id 和 available 被忽略。
+//
+// 每条记录单独校验，非法记录跳过并记录原因；导入的房间自动分配 ID，剩余数量等于总数
+func importRooms(path string) (importResult, error) {
+	var result importResult
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return result, err
+	}
+	var candidates []Room
+	var labels []string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &candidates); err != nil {
+			return result, fmt.Errorf("解析 JSON 失败：%v", err)
+		}
+		for i := range candidates {
+			labels = append(labels, fmt.Sprintf("第 %d 条", i+1))
+		}
+	} else {
+		r := csv.NewReader(strings.NewReader(string(data)))
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			return result, fmt.Errorf("解析 CSV 失败：%v", err)
+		}
+		for i, record := range records {
+			if i == 0 {
+				continue // 跳过表头
+			}
+			label := fmt.Sprintf("第 %d 行", i+1)
+			room, err := roomFromCSVRecord(record)
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s：%v", label, err))
+				continue
+			}
+			candidates = append(candidates, room)
+			labels = append(labels, label)
+		}
+	}
+	for i, room := range candidates {
+		roomType := strings.TrimSpace(room.Type)
+		if roomType == "" {
+			result.Skipped = append(result.Skipped, labels[i]+"：房间类型为空")
+			continue
+		}
+		if existing := findRoomByType(roomType, 0); existing != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s：房型“%s”已存在（ID: %d）", labels[i], roomType, existing.ID))
+			continue
+		}
+		priceWarning, err := validateRoomPrice(room.Price)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s：%v", labels[i], err))
+			continue
+		}
+		totalWarning, err := validateRoomTotal(room.Total)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s：%v", labels[i], err))
+			continue
+		}
+		for _, warning := range []string{priceWarning, totalWarning} {
+			if warning != "" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s：%s", labels[i], warning))
+			}
+		}
+		pricingUnit, err := parsePricingUnit(room.PricingUnit)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s：%v", labels[i], err))
+			continue
+		}
+		if err := validateRoomCapacity(room.Capacity); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s：%v", labels[i], err))
+			continue
+		}
+		createRoom(roomType, room.Price, room.Total, room.Description, room.Facilities, pricingUnit, room.Capacity)
+		result.Imported++
+	}
+	return result, nil
+}
+
+// roomFromCSVRecord 把一行 CSV 记录解析为房间，列顺序为 类型,价格,总数,描述,设施
+func roomFromCSVRecord(record []string) (Room, error) {
+	if len(record) < 3 {
+		return Room{}, errors.New("列数不足，至少需要类型、价格、总数")
+	}
+	price, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+	if err != nil {
+		return Room{}, fmt.Errorf("无效的价格“%s”", record[1])
+	}
+	total, err := strconv.Atoi(strings.TrimSpace(record[2]))
+	if err != nil {
+		return Room{}, fmt.Errorf("无效的房间总数“%s”", record[2])
+	}
+	room := Room{Type: record[0], Price: price, Total: total}
+	if len(record) > 3 {
+		room.Description = strings.TrimSpace(record[3])
+	}
+	if len(record) > 4 {
+		room.Facilities = parseFacilities(record[4])
+	}
+	if len(record) > 5 {
+		room.PricingUnit = strings.TrimSpace(record[5])
+	}
+	return room, nil
+}
+
+// printImportResult 打印批量导入的统计和被跳过、有警告的记录
+func printImportResult(result importResult) {
+	fmt.Printf("导入完成：成功 %d 条，失败 %d 条\n", result.Imported, len(result.Skipped))
+	for _, skipped := range result.Skipped {
+		fmt.Println("  跳过 " + skipped)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Println("  警告 " + warning)
+	}
+}
+
+// importRoomsMenu 管理员输入文件路径批量导入房间，导入前需再次输入管理员密码
+func importRoomsMenu(current *User) {
+	fmt.Print("请输入要导入的文件路径（.csv 或 .json）：")
+	path := strings.TrimSpace(readLine())
+	if path == "" {
+		fmt.Println("文件路径不能为空")
+		return
+	}
+	if !confirmAdminPassword(current, "批量导入房间") {
+		return
+	}
+	result, err := importRooms(path)
+	if err != nil {
+		fmt.Println("导入房间错误：", err)
+		return
+	}
+	printImportResult(result)
+}
+
+// toggleRoomEnabled 管理员下架或重新上架房间；下架不影响已有预订，只是不再接受新预订
+func toggleRoomEnabled() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	action, state := "下架", "已上架"
+	if room.Disabled {
+		action, state = "上架", "已下架"
+	}
+	fmt.Printf("房间 %d（%s）当前%s，确定要%s吗？(y/n): ", room.ID, room.Type, state, action)
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		return
+	}
+	room.Disabled = !room.Disabled
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("%s房间 %d", action, room.ID), "成功")
+	fmt.Printf("房间已%s\n", action)
+	if room.Disabled {
+		if active := activeBookingsForRoom(room.ID); len(active) > 0 {
+			fmt.Printf("注意：该房间仍有 %d 个未取消的预订，下架不会自动取消\n", len(active))
+		}
+	}
+}
+
+// ------------------------- 维护窗口 ----------------------------
+
+// maintenanceOverlaps 判断入住区间 [checkIn, checkOut) 是否有某一晚落在维护窗口 [Start, End] 内。
+// 入住区间退房当天不占用，因此退房日等于维护开始日时不冲突，入住日等于维护结束日时冲突；
+// 没有日期的旧预订视为占用所有日期，总是冲突
+func maintenanceOverlaps(window MaintenanceWindow, checkIn, checkOut string) bool {
+	if checkIn == "" || checkOut == "" {
+		return true
+	}
+	return checkIn <= window.End && window.Start < checkOut
+}
+
+// windowsOverlap 判断两个维护窗口是否有共同的日期（首尾两天都计入）
+func windowsOverlap(a, b MaintenanceWindow) bool {
+	return a.Start <= b.End && b.Start <= a.End
+}
+
+// maintenanceConflict 返回房间在 [checkIn, checkOut) 区间内遇到的第一个维护窗口
+func maintenanceConflict(room Room, checkIn, checkOut string) (MaintenanceWindow, bool) {
+	for _, window := range room.Maintenance {
+		if maintenanceOverlaps(window, checkIn, checkOut) {
+			return window, true
+		}
+	}
+	return MaintenanceWindow{}, false
+}
+
+// maintenanceLabel 返回维护窗口的显示文字，如 "2026-12-01 至 2026-12-03（装修）"
+func maintenanceLabel(window MaintenanceWindow) string {
+	label := window.Start + " 至 " + window.End
+	if window.Reason != "" {
+		label += "（" + window.Reason + "）"
+	}
+	return label
+}
+
+// newMaintenanceWindow 校验日期并构造维护窗口，新窗口不能与房间已有的窗口重叠
+func newMaintenanceWindow(room Room, start, end, reason string) (MaintenanceWindow, error) {
+	startDate, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return MaintenanceWindow{}, errors.New("开始日期格式错误")
+	}
+	endDate, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return MaintenanceWindow{}, errors.New("结束日期格式错误")
+	}
+	if endDate.Before(startDate) {
+		return MaintenanceWindow{}, errors.New("结束日期不能早于开始日期")
+	}
+	window := MaintenanceWindow{Start: startDate.Format(dateLayout), End: endDate.Format(dateLayout), Reason: reason}
+	for _, existing := range room.Maintenance {
+		if windowsOverlap(existing, window) {
+			return MaintenanceWindow{}, fmt.Errorf("与已有维护期 %s 重叠", maintenanceLabel(existing))
+		}
+	}
+	return window, nil
+}
+
+// maintenanceMenu 管理某个房间的维护窗口：查看、添加和删除
+func maintenanceMenu() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	for {
+		fmt.Printf("----- 房间 %d（%s）维护窗口 -----\n", room.ID, room.Type)
+		if len(room.Maintenance) == 0 {
+			fmt.Println("暂无维护安排")
+		}
+		for i, window := range room.Maintenance {
+			fmt.Printf("%d. %s\n", i+1, maintenanceLabel(window))
+		}
+		fmt.Println("1. 添加维护窗口")
+		fmt.Println("2. 删除维护窗口")
+		fmt.Println("3. 返回")
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			addMaintenanceWindow(room)
+		case "2":
+			fmt.Print("请输入要删除的维护窗口序号：")
+			index, err := strconv.Atoi(readLine())
+			if err != nil || index < 1 || index > len(room.Maintenance) {
+				fmt.Println("无效的序号")
+				continue
+			}
+			window := room.Maintenance[index-1]
+			room.Maintenance = append(room.Maintenance[:index-1:index-1], room.Maintenance[index:]...)
+			saveRooms()
+			logOperation(operatorName(), fmt.Sprintf("删除房间 %d 维护窗口 %s", room.ID, maintenanceLabel(window)), "成功")
+			fmt.Println("维护窗口已删除")
+		case "3":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// addMaintenanceWindow 为房间添加维护窗口；与已有预订冲突时提示管理员，确认后才添加，已有预订不会被自动取消
+func addMaintenanceWindow(room *Room) {
+	fmt.Printf("请输入维护开始日期（格式 %s）：", dateLayout)
+	start := readLine()
+	fmt.Printf("请输入维护结束日期（含当天，格式 %s）：", dateLayout)
+	end := readLine()
+	fmt.Print("请输入维护原因（可留空）：")
+	reason := readLine()
+	window, err := newMaintenanceWindow(*room, start, end, reason)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	affected := 0
+	for _, booking := range activeBookingsForRoom(room.ID) {
+		if maintenanceOverlaps(window, booking.CheckIn, booking.CheckOut) {
+			affected++
+		}
+	}
+	if affected > 0 {
+		fmt.Printf("注意：维护期内已有 %d 个未取消的预订，添加维护窗口不会自动取消这些预订，确定要添加吗？(y/n): ", affected)
+		if confirm := readLine(); confirm != "y" && confirm != "Y" {
+			return
+		}
+	}
+	room.Maintenance = append(room.Maintenance, window)
+	sort.Slice(room.Maintenance, func(i, j int) bool {
+		return room.Maintenance[i].Start < room.Maintenance[j].Start
+	})
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("添加房间 %d 维护窗口 %s", room.ID, maintenanceLabel(window)), "成功")
+	fmt.Println("维护窗口已添加")
+}
+
+// ------------------------- 房间号 ----------------------------
+
+// 房间号的限制
+const (
+	maxUnitNumberLength = 10  // 房间号最多的字符数
+	maxFloor            = 200 // 楼层的最大值
+)
+
+// validateUnitNumber 校验房间号非空、不超过 maxUnitNumberLength 个字符，且不含空白或分隔符
+func validateUnitNumber(number string) error {
+	if number == "" {
+		return errors.New("房间号不能为空")
+	}
+	if len([]rune(number)) > maxUnitNumberLength {
+		return fmt.Errorf("房间号不能超过 %d 个字符", maxUnitNumberLength)
+	}
+	if strings.ContainsAny(number, " \t,，、") {
+		return errors.New("房间号不能包含空格或逗号")
+	}
+	return nil
+}
+
+// findUnitOwner 在所有房间中查找房间号，返回所属房间及其在 Units 中的下标，找不到时返回 nil 和 -1
+func findUnitOwner(number string) (*Room, int) {
+	for i := range rooms {
+		for j, unit := range rooms[i].Units {
+			if unit.Number == number {
+				return &rooms[i], j
+			}
+		}
+	}
+	return nil, -1
+}
+
+// newRoomUnit 校验并生成要加入 room 的房间号：房间号全酒店唯一，楼层在 1 到 maxFloor 之间，
+// 且登记的房间号数量不能超过房间总数
+func newRoomUnit(room Room, number string, floor int) (RoomUnit, error) {
+	if err := validateUnitNumber(number); err != nil {
+		return RoomUnit{}, err
+	}
+	if floor < 1 || floor > maxFloor {
+		return RoomUnit{}, fmt.Errorf("楼层必须在 1 到 %d 之间", maxFloor)
+	}
+	if len(room.Units) >= room.Total {
+		return RoomUnit{}, fmt.Errorf("已登记的房间号数量已达房间总数 %d，请先调整总数", room.Total)
+	}
+	if owner, _ := findUnitOwner(number); owner != nil {
+		return RoomUnit{}, fmt.Errorf("房间号 %s 已属于房间 %d（%s）", number, owner.ID, owner.Type)
+	}
+	return RoomUnit{Number: number, Floor: floor, Status: roomUnitActive}, nil
+}
+
+// sortRoomUnits 按楼层、房间号排列房间号
+func sortRoomUnits(units []RoomUnit) {
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].Floor != units[j].Floor {
+			return units[i].Floor < units[j].Floor
+		}
+		return units[i].Number < units[j].Number
+	})
+}
+
+// roomUnitLabel 返回房间号的显示文字，如 "301（3楼）"，停用时注明
+func roomUnitLabel(unit RoomUnit) string {
+	if unit.Status == roomUnitDisabled {
+		return fmt.Sprintf("%s（%d楼，停用）", unit.Number, unit.Floor)
+	}
+	return fmt.Sprintf("%s（%d楼）", unit.Number, unit.Floor)
+}
+
+// bookingUnitsLabel 返回订单分配的房间号，未分配时返回 "未分配"
+func bookingUnitsLabel(booking Booking) string {
+	if len(booking.UnitNumbers) == 0 {
+		return "未分配"
+	}
+	return strings.Join(booking.UnitNumbers, "、")
+}
+
+// hasUnitNumber 判断订单是否分配了某个房间号
+func hasUnitNumber(booking Booking, number string) bool {
+	for _, assigned := range booking.UnitNumbers {
+		if assigned == number {
+			return true
+		}
+	}
+	return false
+}
+
+// unitBookings 返回 list 中分配了该房间号且仍占用库存（已预订或已入住）的订单
+func unitBookings(list []Booking, number string) []Booking {
+	var result []Booking
+	for _, booking := range list {
+		if bookingHoldsRoom(booking) && hasUnitNumber(booking, number) {
+			result = append(result, booking)
+		}
+	}
+	return result
+}
+
+// freeUnits 返回 room 中在 [checkIn, checkOut) 内空闲的可用房间号，顺序同 room.Units；
+// 与区间重叠、仍占用库存且已分配该房间号的订单视为占用，excludeID 对应的订单不计入（重新分配时排除自身）
+func freeUnits(room Room, list []Booking, checkIn, checkOut string, excludeID int) []string {
+	probe := Booking{CheckIn: checkIn, CheckOut: checkOut}
+	var result []string
+	for _, unit := range room.Units {
+		if unit.Status != roomUnitActive {
+			continue
+		}
+		busy := false
+		for _, booking := range list {
+			if booking.ID != excludeID && bookingHoldsRoom(booking) && hasOverlap(booking, probe) && hasUnitNumber(booking, unit.Number) {
+				busy = true
+				break
+			}
+		}
+		if !busy {
+			result = append(result, unit.Number)
+		}
+	}
+	return result
+}
+
+// assignUnits 为订单的每一项自动挑选空闲房间号，优先保留订单原有的分配，其余按楼层、房间号顺序挑选。
+// 某项的房型未登记房间号或空闲房间号不足该项数量时，该项整体不分配
+func assignUnits(list []Booking, booking Booking) []string {
+	var result []string
+	for _, item := range bookingItems(booking) {
+		room := findRoomByID(item.RoomID)
+		if room == nil {
+			continue
+		}
+		free := freeUnits(*room, list, booking.CheckIn, booking.CheckOut, booking.ID)
+		if len(free) < item.Quantity {
+			continue
+		}
+		sort.SliceStable(free, func(i, j int) bool {
+			return hasUnitNumber(booking, free[i]) && !hasUnitNumber(booking, free[j])
+		})
+		result = append(result, free[:item.Quantity]...)
+	}
+	return result
+}
+
+// checkUnitAssignment 校验管理员为订单指定的房间号：每个房间号都属于订单中的房型、不重复、未停用，
+// 且订单入住期间未分配给其它订单；订单中每个登记了房间号的房型都要指定与数量相同的房间号
+func checkUnitAssignment(list []Booking, booking Booking, numbers []string) error {
+	perRoom := make(map[int]int)
+	seen := make(map[string]bool)
+	probe := Booking{CheckIn: booking.CheckIn, CheckOut: booking.CheckOut}
+	for _, number := range numbers {
+		if seen[number] {
+			return fmt.Errorf("房间号 %s 重复", number)
+		}
+		seen[number] = true
+		owner, index := findUnitOwner(number)
+		if owner == nil {
+			return fmt.Errorf("房间号 %s 不存在", number)
+		}
+		if bookingRoomQuantity(booking, owner.ID) == 0 {
+			return fmt.Errorf("房间号 %s 属于%s，不在该订单的房型中", number, owner.Type)
+		}
+		if owner.Units[index].Status != roomUnitActive {
+			return fmt.Errorf("房间号 %s 已停用", number)
+		}
+		for _, other := range list {
+			if other.ID != booking.ID && bookingHoldsRoom(other) && hasOverlap(other, probe) && hasUnitNumber(other, number) {
+				return fmt.Errorf("房间号 %s 在该时段已分配给订单 %s", number, bookingNo(other))
+			}
+		}
+		perRoom[owner.ID]++
+	}
+	for _, item := range bookingItems(booking) {
+		room := findRoomByID(item.RoomID)
+		if room == nil || len(room.Units) == 0 {
+			continue
+		}
+		if perRoom[item.RoomID] != item.Quantity {
+			return fmt.Errorf("%s需要指定 %d 个房间号，当前指定了 %d 个", room.Type, item.Quantity, perRoom[item.RoomID])
+		}
+	}
+	return nil
+}
+
+// roomUnitMenu 管理某个房型下的房间号：查看、添加、删除、停用或启用
+func roomUnitMenu() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	for {
+		fmt.Printf("----- 房间 %d（%s）房间号，已登记 %d/%d -----\n", room.ID, room.Type, len(room.Units), room.Total)
+		if len(room.Units) == 0 {
+			fmt.Println("暂未登记房间号，预订时按数量分配库存")
+		}
+		for _, unit := range room.Units {
+			fmt.Println(roomUnitLabel(unit))
+		}
+		fmt.Println("1. 添加房间号")
+		fmt.Println("2. 删除房间号")
+		fmt.Println("3. 停用/启用房间号")
+		fmt.Println("4. 返回")
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			addRoomUnit(room)
+		case "2":
+			removeRoomUnit(room)
+		case "3":
+			toggleRoomUnit(room)
+		case "4":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// addRoomUnit 为房间登记一个新的房间号
+func addRoomUnit(room *Room) {
+	fmt.Print("请输入房间号：")
+	number := strings.TrimSpace(readLine())
+	fmt.Print("请输入楼层：")
+	floor, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的楼层")
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	unit, err := newRoomUnit(*room, number, floor)
+	logOperation(operatorName(), fmt.Sprintf("房间 %d 添加房间号 %s", room.ID, number), resultOf(err))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	room.Units = append(room.Units, unit)
+	sortRoomUnits(room.Units)
+	saveRooms()
+	fmt.Println("房间号已添加")
+}
+
+// removeRoomUnit 删除房间号；已分配给未完成订单的房间号需先调整分配才能删除
+func removeRoomUnit(room *Room) {
+	fmt.Print("请输入要删除的房间号：")
+	number := strings.TrimSpace(readLine())
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	owner, index := findUnitOwner(number)
+	if owner != room {
+		fmt.Println("该房间下没有这个房间号")
+		return
+	}
+	if assigned := unitBookings(bookings, number); len(assigned) > 0 {
+		fmt.Printf("房间号 %s 已分配给订单 %s，请先为这些订单重新分配房间号\n", number, bookingNo(assigned[0]))
+		return
+	}
+	room.Units = append(room.Units[:index:index], room.Units[index+1:]...)
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("房间 %d 删除房间号 %s", room.ID, number), "成功")
+	fmt.Println("房间号已删除")
+}
+
+// toggleRoomUnit 停用或启用房间号；停用不会改变已有订单的分配，只是不再参与新的分配
+func toggleRoomUnit(room *Room) {
+	fmt.Print("请输入房间号：")
+	number := strings.TrimSpace(readLine())
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	owner, index := findUnitOwner(number)
+	if owner != room {
+		fmt.Println("该房间下没有这个房间号")
+		return
+	}
+	unit := &room.Units[index]
+	action := "启用"
+	if unit.Status == roomUnitActive {
+		unit.Status = roomUnitDisabled
+		action = "停用"
+		if assigned := unitBookings(bookings, number); len(assigned) > 0 {
+			fmt.Printf("注意：该房间号已分配给 %d 个未完成的订单，停用不会改变这些订单的分配\n", len(assigned))
+		}
+	} else {
+		unit.Status = roomUnitActive
+	}
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("%s房间号 %s", action, number), "成功")
+	fmt.Printf("房间号 %s 已%s\n", number, action)
+}
+
+// assignBookingUnitsMenu 管理员为未完成的订单分配房间号：直接回车自动分配，或输入用逗号分隔的房间号手动指定。
+// 等待输入房间号时不持有 dataMu，输入后加锁重新查找订单并校验
+func assignBookingUnitsMenu() {
+	fmt.Print("请输入订单号：")
+	orderNo := strings.TrimSpace(readLine())
+	booking := findBookingByNo(orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if !bookingHoldsRoom(*booking) {
+		fmt.Printf("该订单%s，无需分配房间号\n", bookingStatusLabel(booking.Status))
+		return
+	}
+	fmt.Printf("订单号: %s, 房间: %s, 入住: %s, 退房: %s, 当前房间号: %s\n",
+		bookingNo(*booking), bookingRoomsLabel(*booking), booking.CheckIn, booking.CheckOut, bookingUnitsLabel(*booking))
+	for _, item := range bookingItems(*booking) {
+		room := findRoomByID(item.RoomID)
+		if room == nil || len(room.Units) == 0 {
+			continue
+		}
+		free := freeUnits(*room, bookings, booking.CheckIn, booking.CheckOut, booking.ID)
+		fmt.Printf("%s（%d 间）可分配的房间号: %s\n", room.Type, item.Quantity, strings.Join(free, "、"))
+	}
+	fmt.Print("请输入房间号，多个用逗号分隔（直接回车自动分配）：")
+	numbers := parseFacilities(readLine())
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	// 输入期间订单可能已被取消或退房，其它订单也可能占用了输入的房间号
+	booking = findBookingByNo(orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if !bookingHoldsRoom(*booking) {
+		fmt.Printf("该订单%s，无需分配房间号\n", bookingStatusLabel(booking.Status))
+		return
+	}
+	if len(numbers) == 0 {
+		numbers = assignUnits(bookings, *booking)
+		if len(numbers) == 0 {
+			fmt.Println("没有足够的空闲房间号可供分配")
+			return
+		}
+	} else if err := checkUnitAssignment(bookings, *booking, numbers); err != nil {
+		fmt.Println(err)
+		return
+	}
+	booking.UnitNumbers = numbers
+	saveBookings()
+	logOperation(operatorName(), fmt.Sprintf("订单 %s 分配房间号 %s", bookingNo(*booking), bookingUnitsLabel(*booking)), "成功")
+	fmt.Printf("订单 %s 已分配房间号: %s\n", bookingNo(*booking), bookingUnitsLabel(*booking))
+}
+
+// ------------------------- 价格历史 ----------------------------
+
+// recordPriceChange 记录一次房间改价并立即保存
+func recordPriceChange(roomID int, oldPrice, newPrice float64) {
+	priceHistory = append(priceHistory, PriceChange{
+		RoomID:    roomID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		ChangedAt: time.Now().Format(timeLayout),
+		Operator:  operatorName(),
+	})
+	savePriceHistory()
+}
+
+// priceHistoryOf 返回某房间的价格变更记录，按记录顺序（即时间先后）排列
+func priceHistoryOf(roomID int) []PriceChange {
+	var result []PriceChange
+	for _, change := range priceHistory {
+		if change.RoomID == roomID {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// showPriceHistory 输入房间 ID 并显示该房间的价格变更历史；已删除房间的历史仍可查看
+func showPriceHistory() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	history := priceHistoryOf(id)
+	if len(history) == 0 {
+		fmt.Println("该房间没有价格变更记录")
+		return
+	}
+	fmt.Printf("----- 房间 %d 价格变更历史 -----\n", id)
+	printPaged(len(history), func(i int) {
+		change := history[i]
+		fmt.Printf("%s  %.2f -> %.2f  操作者: %s\n", change.ChangedAt, change.OldPrice, change.NewPrice, change.Operator)
+	})
+	if room := findRoomByID(id); room != nil {
+		fmt.Printf("当前价格: %.2f%s\n", room.Price, priceUnitSuffix(*room))
+	}
+}
+
+// ------------------------- 撤销操作 ----------------------------
+
+// 可撤销的操作类型。只支持不涉及顾客余额的房间操作：
+//   - undoDeleteRoom：删除没有未取消预订的房间，撤销时按原 ID 恢复该房间；
+//   - undoUpdateRooms：修改房间价格或总数（含批量调价和为已有房型增加数量），撤销时只恢复操作前的价格和总数，
+//     同一次修改中对类型、描述等其它信息的改动保留不变。
+//
+// 连带退订的删除、预订、充值等涉及退款或余额的操作不可撤销
+const (
+	undoDeleteRoom  = "delete_room"
+	undoUpdateRooms = "update_rooms"
+)
+
+// maxUndoSteps 为撤销栈保留的最大步数，超出时丢弃最早的记录
+const maxUndoSteps = 10
+
+// undoEntry 记录一次可撤销的操作及受影响房间在操作前的快照
+type undoEntry struct {
+	Kind   string
+	Action string // 操作说明，用于撤销前提示
+	Rooms  []Room
+}
+
+// undoStack 为内存中的撤销栈，程序退出后不保留
+var undoStack []undoEntry
+
+// pushUndo 把一次操作压入撤销栈
+func pushUndo(entry undoEntry) {
+	undoStack = append(undoStack, entry)
+	if len(undoStack) > maxUndoSteps {
+		undoStack = undoStack[len(undoStack)-maxUndoSteps:]
+	}
+}
+
+// applyUndo 撤销 entry 记录的操作，任一房间无法恢复时返回错误且不修改任何数据。
+// 撤销修改时只恢复价格和总数，恢复总数时按总数差额同步调整剩余数量，保留操作之后产生的预订；
+// 改价的恢复同样记入价格历史
+func applyUndo(entry undoEntry) error {
+	switch entry.Kind {
+	case undoDeleteRoom:
+		room := entry.Rooms[0]
+		if findRoomByID(room.ID) != nil {
+			return fmt.Errorf("房间 ID %d 已被新房间占用，无法恢复", room.ID)
+		}
+		for _, unit := range room.Units {
+			if owner, _ := findUnitOwner(unit.Number); owner != nil {
+				return fmt.Errorf("房间号 %s 已被房间 %d 使用，无法恢复", unit.Number, owner.ID)
+			}
+		}
+		rooms = append(rooms, room)
+		sort.Slice(rooms, func(i, j int) bool {
+			return rooms[i].ID < rooms[j].ID
+		})
+		return nil
+	case undoUpdateRooms:
+		restored := make([]Room, len(entry.Rooms))
+		for i, before := range entry.Rooms {
+			current := findRoomByID(before.ID)
+			if current == nil {
+				return fmt.Errorf("房间 %d 已被删除，无法恢复", before.ID)
+			}
+			if err := validateTotalChange(*current, before.Total); err != nil {
+				return fmt.Errorf("房间 %d：%v", before.ID, err)
+			}
+			restored[i] = *current
+			restored[i].Price = before.Price
+			restored[i].Total = before.Total
+			restored[i].Available = current.Available + before.Total - current.Total
+		}
+		for _, room := range restored {
+			current := findRoomByID(room.ID)
+			if current.Price != room.Price {
+				recordPriceChange(room.ID, current.Price, room.Price)
+			}
+			*current = room
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的操作类型: %s", entry.Kind)
+	}
+}
+
+// undoLastMenu 显示最近一次可撤销的操作，确认后撤销并保存
+func undoLastMenu() {
+	if len(undoStack) == 0 {
+		fmt.Println("没有可撤销的操作")
+		return
+	}
+	entry := undoStack[len(undoStack)-1]
+	fmt.Printf("上一步操作: %s（还可撤销 %d 步）\n", entry.Action, len(undoStack))
+	fmt.Print("确定要撤销吗？(y/n): ")
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	err := applyUndo(entry)
+	logOperation(operatorName(), "撤销："+entry.Action, resultOf(err))
+	// 无法恢复的记录同样出栈，避免挡住更早的可撤销操作
+	undoStack = undoStack[:len(undoStack)-1]
+	if err != nil {
+		fmt.Printf("撤销失败：%v，该记录已移出撤销栈\n", err)
+		return
+	}
+	saveRooms()
+	fmt.Println("已撤销：" + entry.Action)
+}
+
+// 批量调价方式
+const (
+	priceAdjustAmount  = "amount"  // 按固定金额增减
+	priceAdjustPercent = "percent" // 按百分比增减
+)
+
+// adjustedPrice 按调价方式计算新价格：amount 为 原价+value，percent 为 原价×(1+value/100)，
+// value 为负表示降价；结果四舍五入到分，新价格不大于 0 时返回错误
+func adjustedPrice(price float64, mode string, value float64) (float64, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, errors.New("无效的调价幅度")
+	}
+	var newPrice float64
+	switch mode {
+	case priceAdjustAmount:
+		newPrice = price + value
+	case priceAdjustPercent:
+		newPrice = price * (1 + value/100)
+	default:
+		return 0, fmt.Errorf("未知的调价方式: %s", mode)
+	}
+	newPrice = math.Round(newPrice*100) / 100
+	if newPrice <= 0 {
+		return 0, fmt.Errorf("调价后价格为 %.2f，必须大于 0", newPrice)
+	}
+	return newPrice, nil
+}
+
+// bulkAdjustPrices 按标准房型或全部房间批量调价：预览受影响房间及新价格，确认后执行并记录价格变更历史。
+// 任一房间调价后价格不合法时整批不执行
+func bulkAdjustPrices() {
+	fmt.Print("请输入要调价的房型（按标准房型匹配，直接回车表示全部房间）：")
+	category := ""
+	if input := readLine(); input != "" {
+		category = normalizeRoomType(input)
+	}
+	var targets []int
+	for i := range rooms {
+		if category == "" || normalizeRoomType(rooms[i].Type) == category {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Printf("没有属于标准房型“%s”的房间\n", category)
+		return
+	}
+	fmt.Print("请选择调价方式（1. 按固定金额 2. 按百分比）：")
+	mode := ""
+	switch readLine() {
+	case "1":
+		mode = priceAdjustAmount
+		fmt.Print("请输入调整金额（正数涨价，负数降价）：")
+	case "2":
+		mode = priceAdjustPercent
+		fmt.Print("请输入调整百分比（如 10 表示涨价 10%，-20 表示降价 20%）：")
+	default:
+		fmt.Println("无效的调价方式")
+		return
+	}
+	value, err := strconv.ParseFloat(readLine(), 64)
+	if err != nil || value == 0 {
+		fmt.Println("无效的调价幅度")
+		return
+	}
+	fmt.Println("----- 调价预览 -----")
+	newPrices := make([]float64, len(targets))
+	valid := true
+	for i, index := range targets {
+		room := rooms[index]
+		newPrice, err := adjustedPrice(room.Price, mode, value)
+		if err != nil {
+			fmt.Printf("ID: %d, 类型: %s, 价格: %.2f -> %v\n", room.ID, room.Type, room.Price, err)
+			valid = false
+			continue
+		}
+		newPrices[i] = newPrice
+		note := ""
+		if warning, _ := validateRoomPrice(newPrice); warning != "" {
+			note = "（" + warning + "）"
+		}
+		fmt.Printf("ID: %d, 类型: %s, 价格: %.2f -> %.2f%s%s\n", room.ID, room.Type, room.Price, newPrice, priceUnitSuffix(room), note)
+	}
+	if !valid {
+		fmt.Println("部分房间调价后价格不合法，本次批量调价未执行")
+		return
+	}
+	fmt.Printf("共 %d 个房间将被调价，确定执行吗？(y/n): ", len(targets))
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		fmt.Println("已取消批量调价")
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	entry := undoEntry{Kind: undoUpdateRooms, Action: fmt.Sprintf("批量调价 %d 个房间", len(targets))}
+	for _, index := range targets {
+		entry.Rooms = append(entry.Rooms, rooms[index])
+	}
+	pushUndo(entry)
+	for i, index := range targets {
+		room := &rooms[index]
+		if newPrices[i] != room.Price {
+			recordPriceChange(room.ID, room.Price, newPrices[i])
+			room.Price = newPrices[i]
+		}
+	}
+	saveRooms()
+	scope := "全部房间"
+	if category != "" {
+		scope = "房型 " + category
+	}
+	logOperation(operatorName(), fmt.Sprintf("批量调价：%s，共 %d 个房间", scope, len(targets)), "成功")
+	fmt.Println("批量调价完成")
+}
+
+// calendarDays 为日历视图默认显示的天数，maxCalendarDays 为允许的最大天数
+const calendarDays = 7
+const maxCalendarDays = 90
+
+// roomCalendar 返回房间从 start 起连续 days 天每晚的剩余可预订数量
+func roomCalendar(roomID int, start time.Time, days int) []int {
+	result := make([]int, days)
+	for i := range result {
+		day := start.AddDate(0, 0, i)
+		result[i] = availableRoomsOn(roomID, day.Format(dateLayout), day.AddDate(0, 0, 1).Format(dateLayout))
+	}
+	return result
+}
+
+// weekdayNames 为星期的中文名称，下标与 time.Weekday 对应
+var weekdayNames = []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+
+// showRoomCalendar 以表格形式显示某房间未来若干天每天的剩余数量
+func showRoomCalendar() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	fmt.Printf("请输入起始日期（格式 %s，回车默认为今天）：", dateLayout)
+	start := time.Now()
+	if input := readLine(); input != "" {
+		start, err = time.Parse(dateLayout, input)
+		if err != nil {
+			fmt.Println("日期格式错误")
+			return
+		}
+	}
+	fmt.Printf("请输入天数（1-%d，回车默认为 %d）：", maxCalendarDays, calendarDays)
+	days := calendarDays
+	if input := readLine(); input != "" {
+		days, err = strconv.Atoi(input)
+		if err != nil || days < 1 || days > maxCalendarDays {
+			fmt.Println("无效的天数")
+			return
+		}
+	}
+	fmt.Printf("----- %s（ID %d，总数 %d）可用日历 -----\n", room.Type, room.ID, room.Total)
+	fmt.Printf("%s%s%s%s\n", padRight("日期", 14), padRight("星期", 8), padRight("已占用", 10), "剩余")
+	for i, available := range roomCalendar(room.ID, start, days) {
+		day := start.AddDate(0, 0, i)
+		date := day.Format(dateLayout)
+		prefix := padRight(date, 14) + padRight(weekdayNames[day.Weekday()], 8)
+		// 维护日与预订占用区分显示，已占用一栏只统计预订
+		if window, ok := maintenanceConflict(*room, date, day.AddDate(0, 0, 1).Format(dateLayout)); ok {
+			fmt.Printf("%s%s维护中（%s）\n", prefix, padRight(strconv.Itoa(bookedRoomsOn(room.ID, date)), 10), window.Reason)
+			continue
+		}
+		fmt.Printf("%s%s%d\n", prefix, padRight(strconv.Itoa(room.Total-available), 10), available)
+	}
+}
+
+// roomTypeDictionary 返回当前的标准房型字典
+func roomTypeDictionary() []roomTypeEntry {
+	if len(settings.RoomTypes) == 0 {
+		return defaultRoomTypes
+	}
+	return settings.RoomTypes
+}
+
+// normalizeRoomType 把房型文本映射为标准房型：与标准名称或别名相同（忽略大小写和首尾空格）即匹配，
+// 都不匹配时归为 otherRoomType
+func normalizeRoomType(input string) string {
+	input = strings.TrimSpace(input)
+	for _, entry := range roomTypeDictionary() {
+		if strings.EqualFold(entry.Name, input) {
+			return entry.Name
+		}
+		for _, alias := range entry.Aliases {
+			if strings.EqualFold(alias, input) {
+				return entry.Name
+			}
+		}
+	}
+	return otherRoomType
+}
+
+// roomCategoryOf 返回房间 ID 对应的标准房型，房间已删除时返回 "(已删除)"
+func roomCategoryOf(roomID int) string {
+	if room := findRoomByID(roomID); room != nil {
+		return normalizeRoomType(room.Type)
+	}
+	return "(已删除)"
+}
+
+// manageRoomTypes 管理员维护标准房型字典
+func manageRoomTypes() {
+	for {
+		fmt.Println(t("menu.types"))
+		printOptions(t("menu.types.list"), t("menu.types.add"), t("menu.types.alias"), t("menu.types.delete"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			for _, entry := range roomTypeDictionary() {
+				aliases := "无"
+				if len(entry.Aliases) > 0 {
+					aliases = strings.Join(entry.Aliases, "、")
+				}
+				fmt.Printf("%s（别名: %s）\n", entry.Name, aliases)
+			}
+			fmt.Printf("%s（无法匹配时的兜底类型）\n", otherRoomType)
+		case "2":
+			fmt.Print("请输入标准房型名称：")
+			name := strings.TrimSpace(readLine())
+			if name == "" || strings.EqualFold(name, otherRoomType) || normalizeRoomType(name) != otherRoomType {
+				fmt.Println("名称为空或已被现有房型、别名占用")
+				continue
+			}
+			settings.RoomTypes = append(roomTypeDictionary(), roomTypeEntry{Name: name})
+			saveSettings()
+			fmt.Printf("已添加标准房型“%s”\n", name)
+		case "3":
+			fmt.Print("请输入标准房型名称：")
+			index := roomTypeIndex(readLine())
+			if index < 0 {
+				fmt.Println("未找到该标准房型")
+				continue
+			}
+			fmt.Print("请输入别名：")
+			alias := strings.TrimSpace(readLine())
+			if alias == "" || strings.EqualFold(alias, otherRoomType) || normalizeRoomType(alias) != otherRoomType {
+				fmt.Println("别名为空或已被现有房型、别名占用")
+				continue
+			}
+			dictionary := append([]roomTypeEntry(nil), roomTypeDictionary()...)
+			dictionary[index].Aliases = append(append([]string(nil), dictionary[index].Aliases...), alias)
+			settings.RoomTypes = dictionary
+			saveSettings()
+			fmt.Printf("已为“%s”添加别名“%s”\n", dictionary[index].Name, alias)
+		case "4":
+			fmt.Print("请输入要删除的标准房型名称：")
+			index := roomTypeIndex(readLine())
+			if index < 0 {
+				fmt.Println("未找到该标准房型")
+				continue
+			}
+			dictionary := roomTypeDictionary()
+			if len(dictionary) == 1 {
+				fmt.Println("至少需要保留一个标准房型")
+				continue
+			}
+			name := dictionary[index].Name
+			settings.RoomTypes = append(append([]roomTypeEntry(nil), dictionary[:index]...), dictionary[index+1:]...)
+			saveSettings()
+			fmt.Printf("已删除标准房型“%s”，原属该房型的房间将归为“%s”\n", name, otherRoomType)
+		case "5":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// roomTypeIndex 返回标准房型在字典中的下标，找不到时返回 -1
+func roomTypeIndex(name string) int {
+	name = strings.TrimSpace(name)
+	for i, entry := range roomTypeDictionary() {
+		if strings.EqualFold(entry.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// addToExistingRoom 为已有房型增加房间数量，总数和剩余数量同步增加，可撤销
+func addToExistingRoom(room *Room) {
+	fmt.Print("请输入要增加的房间数量：")
+	count, err := strconv.Atoi(readLine())
+	if err != nil || count <= 0 {
+		fmt.Println("无效的房间数量")
+		return
+	}
+	before := *room
+	room.Total += count
+	room.Available += count
+	saveRooms()
+	pushUndo(undoEntry{Kind: undoUpdateRooms, Action: fmt.Sprintf("房间 %d 增加 %d 间", room.ID, count), Rooms: []Room{before}})
+	logOperation(operatorName(), fmt.Sprintf("房间 %d 增加 %d 间", room.ID, count), "成功")
+	fmt.Printf("已为房型“%s”增加 %d 间，当前总数: %d\n", room.Type, count, room.Total)
+}
+
+// findRoomByType 查找与给定类型同名的房间（忽略大小写和首尾空白），
+// excludeID 用于在修改房间时排除房间自身，找不到时返回 nil
+func findRoomByType(roomType string, excludeID int) *Room {
+	roomType = strings.ToLower(strings.TrimSpace(roomType))
+	for i := range rooms {
+		if rooms[i].ID != excludeID && strings.ToLower(strings.TrimSpace(rooms[i].Type)) == roomType {
+			return &rooms[i]
+		}
+	}
+	return nil
+}
+
+// updateRoom 修改房间信息
+func updateRoom() {
+	fmt.Print("请输入要修改的房间ID：")
+	idStr := readLine()
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	var room *Room
+	for i := range rooms {
+		if rooms[i].ID == id {
+			room = &rooms[i]
+			break
+		}
+	}
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	before := *room
+	fmt.Printf("当前房间类型: %s\n", room.Type)
+	fmt.Print("请输入新的房间类型（回车保持不变）：")
+	newType := readLine()
+	if newType != "" {
+		if other := findRoomByType(newType, room.ID); other != nil {
+			fmt.Printf("房型“%s”已被房间 %d 使用，房间类型保持不变\n", other.Type, other.ID)
+		} else {
+			room.Type = newType
+		}
+	}
+	room.PricingUnit = choosePricingUnit(room.PricingUnit)
+	fmt.Printf("当前价格: %.2f%s\n", room.Price, priceUnitSuffix(*room))
+	fmt.Print("请输入新的价格（回车保持不变）：")
+	priceStr := readLine()
+	if priceStr != "" {
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err != nil {
+			fmt.Println("无效的价格输入")
+		} else if checkRoomValue(validateRoomPrice(price)) {
+			if price != room.Price {
+				recordPriceChange(room.ID, room.Price, price)
+			}
+			room.Price = price
+		} else {
+			fmt.Println("价格保持不变")
+		}
+	}
+	fmt.Printf("当前总数: %d\n", room.Total)
+	fmt.Print("请输入新的总数（回车保持不变）：")
+	totalStr := readLine()
+	if totalStr != "" {
+		total, err := strconv.Atoi(totalStr)
+		if err != nil {
+			fmt.Println("无效的数量输入")
+		} else if err := validateTotalChange(*room, total); err != nil {
+			fmt.Printf("%v，总数保持不变\n", err)
+		} else if checkRoomValue(validateRoomTotal(total)) {
+			// 已预订数量不变，剩余数量随总数同步调整
+			diff := total - room.Total
+			room.Total = total
+			room.Available += diff
+		} else {
+			fmt.Println("总数保持不变")
+		}
+	}
+	fmt.Printf("当前描述: %s\n", room.Description)
+	fmt.Print("请输入新的描述（回车保持不变）：")
+	if description := readLine(); description != "" {
+		room.Description = description
+	}
+	fmt.Printf("当前设施: %s\n", strings.Join(room.Facilities, ","))
+	fmt.Print("请输入新的设施列表，用逗号分隔（回车保持不变）：")
+	if facilities := readLine(); facilities != "" {
+		room.Facilities = parseFacilities(facilities)
+	}
+	fmt.Printf("当前可住人数: %s\n", capacityLabel(*room))
+	fmt.Print("请输入新的可住人数（回车保持不变，输入 0 按房型推断）：")
+	if input := readLine(); input != "" {
+		capacity, err := strconv.Atoi(input)
+		if err == nil {
+			err = validateRoomCapacity(capacity)
+		}
+		if err != nil {
+			fmt.Println("无效的可住人数，保持不变")
+		} else {
+			room.Capacity = capacity
+		}
+	}
+	fmt.Printf("当前标签: %s\n", strings.Join(room.Tags, ","))
+	fmt.Print("请输入新的标签列表，如 海景,商务,亲子，用逗号分隔（回车保持不变，输入 - 清空）：")
+	if tags := readLine(); tags == "-" {
+		room.Tags = nil
+	} else if tags != "" {
+		room.Tags = parseTags(tags)
+	}
+	saveRooms()
+	if room.Price != before.Price || room.Total != before.Total {
+		pushUndo(undoEntry{Kind: undoUpdateRooms, Action: fmt.Sprintf("修改房间 %d 的价格或库存", room.ID), Rooms: []Room{before}})
+	}
+	logOperation(operatorName(), fmt.Sprintf("修改房间 %d", room.ID), "成功")
+	fmt.Println("房间信息更新成功")
+}
+
+// deleteRoom 删除房间（仅管理员操作），删除前需再次输入管理员密码
+func deleteRoom(current *User) {
+	fmt.Print("请输入要删除的房间ID：")
+	idStr := readLine()
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	index := -1
+	for i, room := range rooms {
+		if room.ID == id {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		fmt.Println("未找到该房间")
+		return
+	}
+	active := activeBookingsForRoom(id)
+	if n := checkedInCount(active); n > 0 {
+		fmt.Printf("该房间有 %d 个已入住的订单，请先办理退房后再删除\n", n)
+		return
+	}
+	if len(active) > 0 {
+		fmt.Printf("该房间还有 %d 个未取消的预订：\n", len(active))
+		printBookingPointers(active)
+		fmt.Print("请先处理这些预订，或选择（1. 强制删除并为这些预订全额退款 其它. 取消删除）：")
+		if readLine() != "1" {
+			fmt.Println("已取消删除")
+			return
+		}
+	}
+	fmt.Print("确定要删除该房间吗？(y/n): ")
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		return
+	}
+	if !confirmAdminPassword(current, fmt.Sprintf("删除房间 %d", id)) {
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	refunded := 0.0
+	for _, booking := range active {
+		refunded += cancelBooking(booking, fmt.Sprintf("房间 %d 已下线", id))
+	}
+	deleted := rooms[index]
+	rooms = append(rooms[:index], rooms[index+1:]...)
+	if len(active) > 0 {
+		saveUsers()
+		saveBookings()
+		fmt.Printf("已取消 %d 个预订，共退款 %.2f 元\n", len(active), refunded)
+	} else {
+		// 连带退订的删除涉及退款和积分，不支持撤销
+		pushUndo(undoEntry{Kind: undoDeleteRoom, Action: fmt.Sprintf("删除房间 %d（%s）", deleted.ID, deleted.Type), Rooms: []Room{deleted}})
+	}
+	saveRooms()
+	logOperation(operatorName(), fmt.Sprintf("删除房间 %d", id), fmt.Sprintf("成功，取消 %d 个预订", len(active)))
+	fmt.Println("房间删除成功")
+}
+
+// activeBookingsForRoom 返回该房间所有仍占用库存的预订（已预订或已入住）的指针
+func activeBookingsForRoom(roomID int) []*Booking {
+	var result []*Booking
+	for i := range bookings {
+		if bookingRoomQuantity(bookings[i], roomID) > 0 && bookingHoldsRoom(bookings[i]) {
+			result = append(result, &bookings[i])
+		}
+	}
+	return result
+}
+
+// printBookingPointers 打印一组预订的指针所指向的记录
+func printBookingPointers(list []*Booking) {
+	var copied []Booking
+	for _, booking := range list {
+		copied = append(copied, *booking)
+	}
+	printBookings(copied)
+}
+
+// cancelBooking 取消一个预订：标记为管理员取消，全额退款给顾客并释放房间库存，返回退款金额。
+// 用于管理员取消订单及删除房间、用户等非顾客原因的取消，会把原因 reason 通知顾客；调用方负责保存数据
+func cancelBooking(booking *Booking, reason string) float64 {
+	booking.CancelledBy = cancelledByAdmin
+	booking.CancelReason = reason
+	sendMessage(booking.UserID, messageTypeCancel, fmt.Sprintf("您的订单 %s（%s，%s 至 %s）已被管理员取消，原因：%s",
+		bookingNo(*booking), bookingRoomsLabel(*booking), booking.CheckIn, booking.CheckOut, reason))
+	return cancelBookingWithRefund(booking, booking.TotalCost)
+}
+
+// cancelBookingWithRefund 取消一个预订并退款 refund 元，订单金额与 refund 的差额作为手续费：
+// 先全额退款再扣除手续费，两笔分别记流水，便于对账。
+// 顾客或房间已被删除时跳过相应的退款或库存释放；调用方负责保存数据
+func cancelBookingWithRefund(booking *Booking, refund float64) float64 {
+	booking.Status = bookingStatusCancelled
+	fee := booking.TotalCost - refund
+	if user := findUserByID(booking.UserID); user != nil {
+		if adjustBalance(user, booking.TotalCost) == nil {
+			recordTransaction(user.ID, booking.TotalCost, transactionTypeRefund, fmt.Sprintf("订单 %s 取消退款", bookingNo(*booking)))
+			if fee > 0 && adjustBalance(user, -fee) == nil {
+				booking.CancelFee = fee
+				recordTransaction(user.ID, -fee, transactionTypeFee, fmt.Sprintf("订单 %s 取消手续费", bookingNo(*booking)))
+			}
+		}
+		// 扣回本单发放的积分
+		adjustPoints(user, -booking.Points)
+		content := fmt.Sprintf("订单 %s 已取消，退款 %.2f 元已到账", bookingNo(*booking), refund)
+		if fee > 0 {
+			content += fmt.Sprintf("（扣除手续费 %.2f 元）", fee)
+		}
+		sendMessage(user.ID, messageTypeRefund, content)
+	}
+	logOperation(operatorName(), fmt.Sprintf("取消订单 %s", bookingNo(*booking)), fmt.Sprintf("成功，退款 %.2f，手续费 %.2f", refund, fee))
+	releaseBookingRooms(*booking)
+	return refund
+}
+
+// releaseBookingRooms 把订单占用的房间数量加回各房间的剩余库存，房间已被删除时跳过
+func releaseBookingRooms(booking Booking) {
+	for _, item := range bookingItems(booking) {
+		if room := findRoomByID(item.RoomID); room != nil {
+			room.Available += item.Quantity
+			if room.Available > room.Total {
+				room.Available = room.Total
+			}
+		}
+	}
+}
+
+// ------------------------- 入住与退房 ----------------------------
+
+// bookingTransitions 列出每个订单状态允许流转到的状态：已预订可办理入住或取消，
+// 已入住只能办理退房；已退房和已取消为终态，不能再流转
+var bookingTransitions = map[string][]string{
+	bookingStatusBooked:    {bookingStatusCheckedIn, bookingStatusCancelled},
+	bookingStatusCheckedIn: {bookingStatusCheckedOut},
+}
+
+// checkBookingTransition 校验订单能否从 from 状态流转到 to 状态，不允许时返回说明原因的错误
+func checkBookingTransition(from, to string) error {
+	for _, next := range bookingTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("订单当前状态为“%s”，不能%s", bookingStatusLabel(from), bookingTransitionAction(to))
+}
+
+// bookingTransitionAction 返回流转到某状态对应的操作名称，用于错误提示
+func bookingTransitionAction(to string) string {
+	switch to {
+	case bookingStatusCheckedIn:
+		return "办理入住"
+	case bookingStatusCheckedOut:
+		return "办理退房"
+	case bookingStatusCancelled:
+		return "取消"
+	default:
+		return "变更为“" + bookingStatusLabel(to) + "”"
+	}
+}
+
+// bookingHoldsRoom 判断订单是否仍占用房间库存：已预订和已入住的订单占用，已退房和已取消的订单已释放
+func bookingHoldsRoom(booking Booking) bool {
+	return booking.Status == bookingStatusBooked || booking.Status == bookingStatusCheckedIn
+}
+
+// checkInBooking 为已预订的订单办理入住，记录入住时间；状态不允许时返回错误且不修改订单。
+// 调用方需持有 dataMu 并负责保存
+func checkInBooking(booking *Booking, now time.Time) error {
+	if err := checkBookingTransition(booking.Status, bookingStatusCheckedIn); err != nil {
+		return err
+	}
+	booking.Status = bookingStatusCheckedIn
+	booking.CheckedInAt = now.Format(timeLayout)
+	return nil
+}
+
+// checkOutBooking 为已入住的订单办理退房，记录退房时间并释放房间库存；状态不允许时返回错误且不修改订单。
+// 提前退房不退还剩余房费，退房日之前的夜晚仍按原订单占用。调用方需持有 dataMu 并负责保存
+func checkOutBooking(booking *Booking, now time.Time) error {
+	if err := checkBookingTransition(booking.Status, bookingStatusCheckedOut); err != nil {
+		return err
+	}
+	booking.Status = bookingStatusCheckedOut
+	booking.CheckedOutAt = now.Format(timeLayout)
+	releaseBookingRooms(*booking)
+	return nil
+}
+
+// 在住客人列表的排序方式
+const (
+	inHouseSortByCheckIn  = "check_in"  // 按入住日期升序，同一天按房型
+	inHouseSortByRoomType = "room_type" // 按房型，同一房型按入住日期升序
+)
+
+// inHouseGuests 返回 list 中已入住尚未退房的订单，按 sortBy 排序，排序键相同时按订单号升序
+func inHouseGuests(list []Booking, sortBy string) []Booking {
+	var result []Booking
+	for _, booking := range list {
+		if booking.Status == bookingStatusCheckedIn {
+			result = append(result, booking)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		typeA, typeB := bookingRoomsLabel(a), bookingRoomsLabel(b)
+		if sortBy == inHouseSortByRoomType && typeA != typeB {
+			return typeA < typeB
+		}
+		if a.CheckIn != b.CheckIn {
+			return a.CheckIn < b.CheckIn
+		}
+		if typeA != typeB {
+			return typeA < typeB
+		}
+		return bookingNo(a) < bookingNo(b)
+	})
+	return result
+}
+
+// showInHouseGuests 打印当前在住客人列表，可选择按入住日期或房型排序，并汇总在住间数
+func showInHouseGuests() {
+	fmt.Print("请选择排序方式（1. 按入住日期 2. 按房型，直接回车按入住日期）：")
+	sortBy := inHouseSortByCheckIn
+	switch readLine() {
+	case "", "1":
+	case "2":
+		sortBy = inHouseSortByRoomType
+	default:
+		fmt.Println("无效的排序选项")
+		return
+	}
+	dataMu.Lock()
+	guests := inHouseGuests(bookings, sortBy)
+	dataMu.Unlock()
+	if len(guests) == 0 {
+		fmt.Println("当前没有在住客人")
+		return
+	}
+	fmt.Println("----- 在住客人 -----")
+	occupied := 0
+	for _, booking := range guests {
+		fmt.Printf("订单号: %s, 顾客: %s, 房型: %s, 数量: %d, 房间号: %s, 入住日期: %s, 预计退房: %s, 办理入住: %s\n",
+			bookingNo(booking), usernameOf(booking.UserID), bookingRoomsLabel(booking), booking.Quantity,
+			bookingUnitsLabel(booking), booking.CheckIn, booking.CheckOut, booking.CheckedInAt)
+		occupied += booking.Quantity
+	}
+	fmt.Printf("共 %d 笔订单在住，占用 %d 间\n", len(guests), occupied)
+}
+
+// checkedInCount 统计列表中已入住的订单数；已入住的订单不能取消，删除房间或用户前需先办理退房
+func checkedInCount(list []*Booking) int {
+	count := 0
+	for _, booking := range list {
+		if booking.Status == bookingStatusCheckedIn {
+			count++
+		}
+	}
+	return count
+}
+
+// findBookingByNo 按订单号查找任意顾客的订单，找不到时返回 nil
+func findBookingByNo(orderNo string) *Booking {
+	for i := range bookings {
+		if bookingNo(bookings[i]) == orderNo {
+			return &bookings[i]
+		}
+	}
+	return nil
+}
+
+// adminChangeBookingStatus 管理员按订单号为订单办理入住或退房，to 为目标状态
+func adminChangeBookingStatus(to string) {
+	fmt.Print("请输入订单号：")
+	orderNo := strings.TrimSpace(readLine())
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	booking := findBookingByNo(orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	fmt.Printf("订单号: %s, 顾客: %s, 房间: %s, 数量: %d, 入住: %s, 退房: %s, 房间号: %s, 状态: %s\n",
+		bookingNo(*booking), usernameOf(booking.UserID), bookingRoomsLabel(*booking), booking.Quantity,
+		booking.CheckIn, booking.CheckOut, bookingUnitsLabel(*booking), bookingStatusText(*booking))
+	action := bookingTransitionAction(to)
+	var err error
+	if to == bookingStatusCheckedIn {
+		err = checkInBooking(booking, time.Now())
+		// 入住时仍未分配房间号的订单自动分配，便于前台告知顾客
+		if err == nil && len(booking.UnitNumbers) == 0 {
+			booking.UnitNumbers = assignUnits(bookings, *booking)
+		}
+	} else {
+		err = checkOutBooking(booking, time.Now())
+	}
+	logOperation(operatorName(), fmt.Sprintf("订单 %s %s", orderNo, action), resultOf(err))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	saveBookings()
+	if to == bookingStatusCheckedOut {
+		saveRooms()
+	}
+	fmt.Printf("订单 %s 已%s\n", bookingNo(*booking), action)
+	if to == bookingStatusCheckedIn && len(booking.UnitNumbers) > 0 {
+		fmt.Printf("房间号: %s\n", bookingUnitsLabel(*booking))
+	}
+}
+
+// maxCancelReasonLength 为管理员取消订单时填写的原因最多的字符数
+const maxCancelReasonLength = 100
+
+// readCancelReason 读取管理员取消订单的原因，原因必填且不超过 maxCancelReasonLength 个字符，
+// 不合法时提示后重新输入；直接输入 q 表示放弃，返回空字符串
+func readCancelReason() string {
+	for {
+		fmt.Printf("请输入取消原因（将通知顾客，最多 %d 字，输入 q 放弃）：", maxCancelReasonLength)
+		reason := strings.TrimSpace(readLine())
+		switch length := len([]rune(reason)); {
+		case reason == "q" || reason == "Q":
+			return ""
+		case length == 0:
+			fmt.Println("取消原因不能为空")
+		case length > maxCancelReasonLength:
+			fmt.Printf("取消原因不能超过 %d 个字符（当前 %d 个）\n", maxCancelReasonLength, length)
+		default:
+			return reason
+		}
+	}
+}
+
+// adminCancelBookingMenu 管理员按订单号取消顾客的预订（如房间故障），全额退款、释放库存并通知顾客原因。
+// 等待输入原因和确认时不持有 dataMu，确认后加锁重新查找并校验订单状态
+func adminCancelBookingMenu() {
+	fmt.Print("请输入订单号：")
+	orderNo := strings.TrimSpace(readLine())
+	booking := findBookingByNo(orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if err := checkBookingTransition(booking.Status, bookingStatusCancelled); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("订单号: %s, 顾客: %s, 房间: %s, 数量: %d, 金额: %.2f, 入住: %s, 退房: %s\n",
+		bookingNo(*booking), usernameOf(booking.UserID), bookingRoomsLabel(*booking), booking.Quantity,
+		booking.TotalCost, booking.CheckIn, booking.CheckOut)
+	shown := *booking
+	reason := readCancelReason()
+	if reason == "" {
+		return
+	}
+	fmt.Printf("将全额退款 %.2f 元给顾客，确定要取消该订单吗？(y/n): ", shown.TotalCost)
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	booking = findBookingByNo(orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	// 输入期间顾客可能已自行取消、办理入住或修改了数量
+	if err := checkBookingTransition(booking.Status, bookingStatusCancelled); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if booking.TotalCost != shown.TotalCost {
+		fmt.Println("订单在确认期间已被修改，请重新操作")
+		return
+	}
+	refund := cancelBooking(booking, reason)
+	saveUsers()
+	saveRooms()
+	saveBookings()
+	logOperation(operatorName(), fmt.Sprintf("管理员取消订单 %s", bookingNo(*booking)), "成功，原因："+reason)
+	fmt.Printf("订单 %s 已取消，已退款 %.2f 元并通知顾客\n", bookingNo(*booking), refund)
+}
+
+// ------------------------- 退款策略 ----------------------------
+
+// defaultRefundPolicy 为默认退款策略：提前 3 天及以上全额退款，提前 1-2 天退 50%，入住当天及之后不退
+var defaultRefundPolicy = []refundRule{
+	{MinDays: 3, Ratio: 1},
+	{MinDays: 1, Ratio: 0.5},
+	{MinDays: 0, Ratio: 0},
+}
+
+// refundPolicy 返回当前使用的退款策略
+func refundPolicy() []refundRule {
+	if len(settings.RefundPolicy) > 0 {
+		return settings.RefundPolicy
+	}
+	return defaultRefundPolicy
+}
+
+// refundRatio 返回距入住日 days 天取消时的退款比例：取 MinDays 不超过 days 的档位中最高的一档，
+// 没有匹配的档位（如已过入住日）时不退款
+func refundRatio(policy []refundRule, days int) float64 {
+	best := -1
+	ratio := 0.0
+	for _, rule := range policy {
+		if rule.MinDays <= days && rule.MinDays > best {
+			best = rule.MinDays
+			ratio = rule.Ratio
+		}
+	}
+	return ratio
+}
+
+// refundAmount 按当前退款策略计算在 now 时刻取消 booking 应退的金额。
+// 距入住日的天数按日期计算，与具体时刻无关；缺少入住日期的旧订单全额退款
+func refundAmount(booking Booking, now time.Time) float64 {
+	checkIn, err := time.ParseInLocation(dateLayout, booking.CheckIn, time.Local)
+	if err != nil {
+		return booking.TotalCost
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	days := int(math.Round(checkIn.Sub(today).Hours() / 24))
+	return booking.TotalCost * refundRatio(refundPolicy(), days)
+}
+
+// parseRefundPolicy 解析“天数:比例”以逗号分隔的退款策略，如 3:1,1:0.5,0:0
+func parseRefundPolicy(input string) ([]refundRule, error) {
+	var policy []refundRule
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(input, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("无效的档位“%s”，格式应为 天数:比例", part)
+		}
+		days, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("无效的天数“%s”", fields[0])
+		}
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil || math.IsNaN(ratio) || ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("无效的退款比例“%s”，应在 0 到 1 之间", fields[1])
+		}
+		if seen[days] {
+			return nil, fmt.Errorf("天数 %d 重复", days)
+		}
+		seen[days] = true
+		policy = append(policy, refundRule{MinDays: days, Ratio: ratio})
+	}
+	sort.Slice(policy, func(i, j int) bool {
+		return policy[i].MinDays > policy[j].MinDays
+	})
+	return policy, nil
+}
+
+// refundPolicyLabel 返回退款策略的显示文字，如“提前 3 天及以上退 100%，提前 1 天及以上退 50%”
+func refundPolicyLabel(policy []refundRule) string {
+	var parts []string
+	for _, rule := range policy {
+		parts = append(parts, fmt.Sprintf("提前 %d 天及以上退 %.0f%%", rule.MinDays, rule.Ratio*100))
+	}
+	return strings.Join(parts, "，")
+}
+
+// bookingItems 返回订单包含的房间明细，单个房间的订单转换为一项
+func bookingItems(booking Booking) []BookingItem {
+	if len(booking.Items) > 0 {
+		return booking.Items
+	}
+	return []BookingItem{{RoomID: booking.RoomID, Quantity: booking.Quantity, UnitPrice: booking.UnitPrice, Cost: booking.TotalCost}}
+}
+
+// bookingRoomQuantity 返回订单中指定房间的数量，不包含该房间时返回 0
+func bookingRoomQuantity(booking Booking, roomID int) int {
+	count := 0
+	for _, item := range bookingItems(booking) {
+		if item.RoomID == roomID {
+			count += item.Quantity
+		}
+	}
+	return count
+}
+
+// bookingRoomsLabel 返回订单房间的显示文字，多房间订单如 "单人间×1、双人间×2"
+func bookingRoomsLabel(booking Booking) string {
+	if len(booking.Items) == 0 {
+		return roomTypeName(booking.RoomID)
+	}
+	var parts []string
+	for _, item := range booking.Items {
+		parts = append(parts, fmt.Sprintf("%s×%d", roomTypeName(item.RoomID), item.Quantity))
+	}
+	return strings.Join(parts, "、")
+}
+
+// filterRoomsByPrice 返回价格在 [min, max] 区间内且仍有剩余的房间
+func filterRoomsByPrice(min, max float64) []Room {
+	var result []Room
+	for _, room := range rooms {
+		if isRoomBookable(room) && room.Price >= min && room.Price <= max {
+			result = append(result, room)
+		}
+	}
+	return result
+}
+
+// adminBookingManagement 管理员查看全部预订，并可按用户、房间或状态过滤
+func adminBookingManagement() {
+	for {
+		fmt.Println(t("menu.bookings"))
+		printOptions(t("menu.bookings.all"), t("menu.bookings.by_user"), t("menu.bookings.by_room"), t("menu.bookings.by_status"),
+			t("menu.bookings.check_in"), t("menu.bookings.check_out"), t("menu.bookings.in_house"),
+			t("menu.bookings.units"), t("menu.bookings.cancel"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			printBookings(filterBookings(0, 0, ""))
+		case "2":
+			fmt.Print("请输入用户ID：")
+			userID, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的ID")
+				continue
+			}
+			printBookings(filterBookings(userID, 0, ""))
+		case "3":
+			fmt.Print("请输入房间ID：")
+			roomID, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的ID")
+				continue
+			}
+			printBookings(filterBookings(0, roomID, ""))
+		case "4":
+			fmt.Print("请选择状态（1. 已预订 2. 已入住 3. 已退房 4. 已取消）：")
+			switch readLine() {
+			case "1":
+				printBookings(filterBookings(0, 0, bookingStatusBooked))
+			case "2":
+				printBookings(filterBookings(0, 0, bookingStatusCheckedIn))
+			case "3":
+				printBookings(filterBookings(0, 0, bookingStatusCheckedOut))
+			case "4":
+				printBookings(filterBookings(0, 0, bookingStatusCancelled))
+			default:
+				fmt.Println("无效的状态选项")
+			}
+		case "5":
+			adminChangeBookingStatus(bookingStatusCheckedIn)
+		case "6":
+			adminChangeBookingStatus(bookingStatusCheckedOut)
+		case "7":
+			showInHouseGuests()
+		case "8":
+			assignBookingUnitsMenu()
+		case "9":
+			adminCancelBookingMenu()
+		case "10":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// filterBookings 按条件过滤预订，userID、roomID 为 0 或 status 为空表示不限该条件，结果按时间倒序
+func filterBookings(userID, roomID int, status string) []Booking {
+	var result []Booking
+	for _, booking := range bookings {
+		if userID != 0 && booking.UserID != userID {
+			continue
+		}
+		if roomID != 0 && bookingRoomQuantity(booking, roomID) == 0 {
+			continue
+		}
+		if status != "" && booking.Status != status {
+			continue
+		}
+		result = append(result, booking)
+	}
+	sortBookingsNewestFirst(result)
+	return result
+}
+
+// printBookings 打印预订列表，包括顾客用户名和房间类型
+func printBookings(list []Booking) {
+	if len(list) == 0 {
+		fmt.Println("没有符合条件的预订记录")
+		return
+	}
+	fmt.Println("----- 预订列表 -----")
+	for _, booking := range list {
+		fmt.Printf("订单号: %s, 顾客: %s, 房间: %s, 数量: %d, 金额: %.2f, 入住: %s, 退房: %s, 下单时间: %s, 状态: %s\n",
+			bookingNo(booking), usernameOf(booking.UserID), bookingRoomsLabel(booking), booking.Quantity, booking.TotalCost,
+			booking.CheckIn, booking.CheckOut, booking.CreatedAt, bookingStatusText(booking))
+		if len(booking.UnitNumbers) > 0 {
+			fmt.Println("    房间号: " + bookingUnitsLabel(booking))
+		}
+		printBookingNote(booking.Note)
+	}
+}
+
+// printBookingNote 在订单行下方缩进打印备注，超出 noteWrapWidth 时折行，备注为空时不打印
+func printBookingNote(note string) {
+	for i, line := range wrapText(note, noteWrapWidth) {
+		if i == 0 {
+			fmt.Println("    备注: " + line)
+		} else {
+			fmt.Println("          " + line)
+		}
+	}
+}
+
+// 预订备注的长度限制和显示宽度
+const (
+	maxNoteLength = 200 // 备注最多的字符数
+	noteWrapWidth = 60  // 显示备注时每行的最大显示宽度
+)
+
+// validateBookingNote 校验备注长度不超过 maxNoteLength 个字符
+func validateBookingNote(note string) error {
+	if length := len([]rune(note)); length > maxNoteLength {
+		return fmt.Errorf("备注不能超过 %d 个字符（当前 %d 个）", maxNoteLength, length)
+	}
+	return nil
+}
+
+// readBookingNote 读取可选的预订备注，直接回车表示无备注；超长时提示后重新输入
+func readBookingNote() string {
+	for {
+		fmt.Printf("请输入备注，如加床、无烟、晚到（最多 %d 字，直接回车跳过）：", maxNoteLength)
+		note := strings.TrimSpace(readLine())
+		if err := validateBookingNote(note); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		return note
+	}
+}
+
+// wrapText 按显示宽度把文字折成多行，每行不超过 width（单个字符超宽时独占一行），空文字返回 nil
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, r := range text {
+		w := displayWidth(string(r))
+		if lineWidth+w > width && lineWidth > 0 {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		line.WriteRune(r)
+		lineWidth += w
+	}
+	if lineWidth > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// usernameOf 返回用户的用户名，用户已被删除时返回 "(已删除)"
+func usernameOf(userID int) string {
+	user := findUserByID(userID)
+	if user == nil {
+		return "(已删除)"
+	}
+	if user.Deleted {
+		return user.Username + "(已删除)"
+	}
+	return user.Username
+}
+
+// findUserByID 按 ID 查找用户（包括已软删除的用户），找不到时返回 nil
+func findUserByID(id int) *User {
+	for i := range users {
+		if users[i].ID == id {
+			return &users[i]
+		}
+	}
+	return nil
+}
+
+// findActiveUserByID 按 ID 查找未被删除的用户，找不到时返回 nil
+func findActiveUserByID(id int) *User {
+	user := findUserByID(id)
+	if user == nil || user.Deleted {
+		return nil
+	}
+	return user
+}
+
+// adjustUserBalance 管理员手动为顾客入账或扣款（如线下付款、退款补偿），必须填写原因并记入流水
+func adjustUserBalance() {
+	fmt.Print("请输入要调整余额的用户ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	user := findActiveUserByID(id)
+	if user == nil {
+		fmt.Println("未找到该用户")
+		return
+	}
+	if user.Role != "customer" {
+		fmt.Println("只能调整顾客账号的余额")
+		return
+	}
+	fmt.Printf("用户 %s 当前余额: %.2f\n", user.Username, user.Balance)
+	fmt.Print("请输入调整金额（正数入账，负数扣款）：")
+	amount, err := strconv.ParseFloat(readLine(), 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) || amount == 0 {
+		fmt.Println("无效的金额，请输入非零数字")
+		return
+	}
+	fmt.Print("请输入调整原因：")
+	reason := strings.TrimSpace(readLine())
+	if reason == "" {
+		fmt.Println("调整原因不能为空")
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	if err := adjustBalance(user, amount); err != nil {
+		fmt.Println(err)
+		return
+	}
+	saveUsers()
+	recordTransaction(user.ID, amount, transactionTypeAdjust, "原因："+reason)
+	fmt.Printf("余额调整成功！调整金额: %+.2f，当前余额: %.2f\n", amount, user.Balance)
+}
+
+// ------------------------- 统计报表 ----------------------------
+
+// roomTypeStat 记录某一房间类型的预订次数和贡献营收
+type roomTypeStat struct {
+	Type    string
+	Count   int
+	Revenue float64
+}
+
+// adminStatistics 管理员查看各类统计报表
+func adminStatistics() {
+	for {
+		fmt.Println(t("menu.stats"))
+		printOptions(t("menu.stats.overview"), t("menu.stats.top_spenders"), t("menu.stats.revenue_range"),
+			t("menu.stats.user_growth"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			showStatistics()
+		case "2":
+			showTopSpenders()
+		case "3":
+			showRevenueByDateRange()
+		case "4":
+			showUserGrowth()
+		case "5":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// showStatistics 输出总营收、各房间类型的预订情况和当前总入住率
+func showStatistics() {
+	fmt.Println("----- 统计报表 -----")
+	fmt.Printf("总营收: %.2f 元\n", totalRevenue())
+	stats := roomTypeStats(bookings)
+	if len(stats) == 0 {
+		fmt.Println("暂无预订数据")
+	} else {
+		fmt.Println("各标准房型预订情况：")
+		for _, stat := range stats {
+			fmt.Printf("  %s: 预订 %d 次, 营收 %.2f 元\n", stat.Type, stat.Count, stat.Revenue)
+		}
+	}
+	booked, total := occupancy()
+	if total == 0 {
+		fmt.Println("当前总入住率: 无房间")
+		return
+	}
+	fmt.Printf("当前总入住率: %.2f%% (%d/%d)\n", float64(booked)*100/float64(total), booked, total)
+	fmt.Println("各标准房型入住率：")
+	for _, stat := range roomTypeOccupancy() {
+		fmt.Printf("  %s %s (%d/%d)\n", padRight(stat.Type, 10), occupancyBar(stat.Booked, stat.Total), stat.Booked, stat.Total)
+	}
+}
+
+// occupancyStat 记录某一标准房型当前已订出的房间数和总房间数
+type occupancyStat struct {
+	Type   string
+	Booked int
+	Total  int
+}
+
+// roomTypeOccupancy 按标准房型汇总当前的入住情况，结果按类型名排序
+func roomTypeOccupancy() []occupancyStat {
+	byType := make(map[string]*occupancyStat)
+	for _, room := range rooms {
+		roomType := normalizeRoomType(room.Type)
+		stat, ok := byType[roomType]
+		if !ok {
+			stat = &occupancyStat{Type: roomType}
+			byType[roomType] = stat
+		}
+		stat.Booked += room.Total - room.Available
+		stat.Total += room.Total
+	}
+	var result []occupancyStat
+	for _, stat := range byType {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// occupancyBarWidth 为入住率条形图的固定格数
+const occupancyBarWidth = 10
+
+// occupancyBar 把入住率绘制为固定宽度的 ASCII 条形图，如 [■■■■■□□□□□] 50%；
+// 格数按比例四舍五入，total 为 0 时显示为空条并标注无房间
+func occupancyBar(booked, total int) string {
+	if total <= 0 {
+		return "[" + strings.Repeat("□", occupancyBarWidth) + "] 无房间"
+	}
+	ratio := float64(booked) / float64(total)
+	filled := int(math.Round(ratio * occupancyBarWidth))
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > occupancyBarWidth {
+		filled = occupancyBarWidth
+	}
+	return fmt.Sprintf("[%s%s] %.0f%%", strings.Repeat("■", filled), strings.Repeat("□", occupancyBarWidth-filled), ratio*100)
+}
+
+// userCounts 统计未删除用户中管理员和顾客的人数
+func userCounts(list []User) (admins, customers int) {
+	for _, user := range list {
+		if user.Deleted {
+			continue
+		}
+		if user.Role == "admin" {
+			admins++
+		} else {
+			customers++
+		}
+	}
+	return admins, customers
+}
+
+// roomInventory 返回房间（房型）数量和所有房间的总库存间数
+func roomInventory(list []Room) (count, total int) {
+	for _, room := range list {
+		total += room.Total
+	}
+	return len(list), total
+}
+
+// ordersOn 统计下单日期为 date（2006-01-02）的订单，返回值同 orderStats
+func ordersOn(list []Booking, date string) (count int, revenue float64) {
+	var day []Booking
+	for _, booking := range list {
+		if strings.HasPrefix(booking.CreatedAt, date) {
+			day = append(day, booking)
+		}
+	}
+	return orderStats(day)
+}
+
+// orderStats 返回 list 中未取消的订单数和按 bookingRevenue 计入的营收（含已取消订单扣除的手续费）
+func orderStats(list []Booking) (count int, revenue float64) {
+	for _, booking := range list {
+		if booking.Status != bookingStatusCancelled {
+			count++
+		}
+		revenue += bookingRevenue(booking)
+	}
+	return count, revenue
+}
+
+// totalCustomerBalance 统计未删除顾客的余额总额
+func totalCustomerBalance(list []User) float64 {
+	sum := 0.0
+	for _, user := range list {
+		if !user.Deleted && user.Role == "customer" {
+			sum += user.Balance
+		}
+	}
+	return sum
+}
+
+// printDashboard 在管理员菜单顶部显示系统概览，每次显示菜单时按当前数据实时计算
+func printDashboard() {
+	now := time.Now()
+	admins, customers := userCounts(users)
+	roomCount, stock := roomInventory(rooms)
+	todayOrders, todayRevenue := ordersOn(bookings, now.Format(dateLayout))
+	booked, total := occupancy()
+	fmt.Printf("----- 系统概览（%s） -----\n", now.Format("2006-01-02 15:04"))
+	fmt.Printf("用户: %d 人（管理员 %d，顾客 %d） | 房间: %d 个，总库存 %d 间\n",
+		admins+customers, admins, customers, roomCount, stock)
+	fmt.Printf("今日订单: %d 笔 | 今日营收: %.2f 元 | 顾客余额合计: %.2f 元\n", todayOrders, todayRevenue, totalCustomerBalance(users))
+	fmt.Printf("当前入住率: %s\n", occupancyBar(booked, total))
+}
+
+// totalRevenue 统计所有预订计入的营收之和，见 bookingRevenue
+func totalRevenue() float64 {
+	sum := 0.0
+	for _, booking := range bookings {
+		sum += bookingRevenue(booking)
+	}
+	return sum
+}
+
+// bookingRevenue 返回预订计入营收的金额：未取消的预订为实付金额，已取消的预订为取消时扣除的手续费
+func bookingRevenue(booking Booking) float64 {
+	if booking.Status == bookingStatusCancelled {
+		return booking.CancelFee
+	}
+	return booking.TotalCost
+}
+
+// roomTypeStats 按标准房型汇总 list 中未取消预订的次数和营收，结果按类型名排序；
+// 已取消预订不计次数，其手续费按各项实付金额的比例计入对应房型的营收
+func roomTypeStats(list []Booking) []roomTypeStat {
+	byType := make(map[string]*roomTypeStat)
+	for _, booking := range list {
+		cancelled := booking.Status == bookingStatusCancelled
+		if cancelled && booking.CancelFee <= 0 {
+			continue
+		}
+		for _, item := range bookingItems(booking) {
+			roomType := roomCategoryOf(item.RoomID)
+			stat, ok := byType[roomType]
+			if !ok {
+				stat = &roomTypeStat{Type: roomType}
+				byType[roomType] = stat
+			}
+			if cancelled {
+				stat.Revenue += item.Cost / booking.TotalCost * booking.CancelFee
+				continue
+			}
+			stat.Count++
+			stat.Revenue += item.Cost
+		}
+	}
+	var result []roomTypeStat
+	for _, stat := range byType {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// bookingsCreatedBetween 返回 list 中下单日期（CreatedAt 的日期部分）在 [start, end] 内的预订（含已取消的预订），
+// start、end 为 2006-01-02 格式且包含首尾两天；日期格式非法或 end 早于 start 时返回错误
+func bookingsCreatedBetween(list []Booking, start, end string) ([]Booking, error) {
+	startDate, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return nil, fmt.Errorf("起始日期格式错误，应为 %s", dateLayout)
+	}
+	endDate, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return nil, fmt.Errorf("结束日期格式错误，应为 %s", dateLayout)
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("结束日期不能早于起始日期")
+	}
+	var result []Booking
+	for _, booking := range list {
+		created, err := time.Parse(timeLayout, booking.CreatedAt)
+		if err != nil {
+			// 缺少下单时间的旧订单无法归入任何区间
+			continue
+		}
+		day := created.Format(dateLayout)
+		if day >= start && day <= end {
+			result = append(result, booking)
+		}
+	}
+	return result, nil
+}
+
+// showRevenueByDateRange 输入起止日期，统计该区间内下单的预订的营收、未取消订单数和各房型分布，
+// 营收包含已取消订单扣除的手续费
+func showRevenueByDateRange() {
+	fmt.Printf("请输入起始日期（格式 %s）：", dateLayout)
+	start := readLine()
+	fmt.Printf("请输入结束日期（格式 %s）：", dateLayout)
+	end := readLine()
+	list, err := bookingsCreatedBetween(bookings, start, end)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("----- %s 至 %s 营收统计 -----\n", start, end)
+	if len(list) == 0 {
+		fmt.Println("该区间内没有订单")
+		return
+	}
+	count, revenue := orderStats(list)
+	fmt.Printf("营收总额: %.2f 元，订单数: %d\n", revenue, count)
+	fmt.Println("各标准房型分布：")
+	for _, stat := range roomTypeStats(list) {
+		fmt.Printf("  %s: 预订 %d 次, 营收 %.2f 元\n", stat.Type, stat.Count, stat.Revenue)
+	}
+}
+
+// 用户增长报表的汇总粒度
+const (
+	growthByDay  = "day"
+	growthByWeek = "week"
+)
+
+// loginEvent 是从操作日志中解析出的一次成功登录
+type loginEvent struct {
+	Time     time.Time
+	Username string
+}
+
+// parseLoginEvents 从 hotel.log 的内容中解析出所有成功登录记录，格式不符的行会被忽略
+func parseLoginEvents(data string) []loginEvent {
+	var events []loginEvent
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.SplitN(line, " | ", 4)
+		if len(fields) != 4 || fields[2] != "登录" || fields[3] != "成功" {
+			continue
+		}
+		at, err := time.Parse(timeLayout, fields[0])
+		if err != nil {
+			continue
+		}
+		events = append(events, loginEvent{Time: at, Username: fields[1]})
+	}
+	return events
+}
+
+// growthPeriod 返回时间所属的汇总周期：按日为当天日期，按周为该周周一的日期
+func growthPeriod(at time.Time, mode string) string {
+	if mode == growthByWeek {
+		// time.Weekday 以周日为 0，换算为距周一的天数
+		offset := (int(at.Weekday()) + 6) % 7
+		at = at.AddDate(0, 0, -offset)
+	}
+	return at.Format(dateLayout)
+}
+
+// growthRow 是用户增长报表中的一行
+type growthRow struct {
+	Period      string // 周期起始日期
+	NewUsers    int    // 该周期内注册的顾客数
+	ActiveUsers int    // 该周期内至少成功登录一次的顾客数（同一顾客只计一次）
+}
+
+// userGrowth 按周期统计顾客的新增注册数和活跃数，结果按周期升序排列；
+// 缺少注册时间的老用户不归入任何周期，其数量作为 unknown 返回。管理员不参与统计
+func userGrowth(list []User, logins []loginEvent, mode string) (rows []growthRow, unknown int) {
+	byPeriod := make(map[string]*growthRow)
+	rowOf := func(period string) *growthRow {
+		row := byPeriod[period]
+		if row == nil {
+			row = &growthRow{Period: period}
+			byPeriod[period] = row
+		}
+		return row
+	}
+	customers := make(map[string]bool)
+	for _, user := range list {
+		if user.Role != "customer" {
+			continue
+		}
+		customers[user.Username] = true
+		created, err := time.Parse(timeLayout, user.CreatedAt)
+		if err != nil {
+			unknown++
+			continue
+		}
+		rowOf(growthPeriod(created, mode)).NewUsers++
+	}
+	active := make(map[string]map[string]bool)
+	for _, event := range logins {
+		if !customers[event.Username] {
+			continue
+		}
+		period := growthPeriod(event.Time, mode)
+		if active[period] == nil {
+			active[period] = make(map[string]bool)
+		}
+		active[period][event.Username] = true
+	}
+	for period, names := range active {
+		rowOf(period).ActiveUsers = len(names)
+	}
+	for _, row := range byPeriod {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Period < rows[j].Period
+	})
+	return rows, unknown
+}
+
+// showUserGrowth 按日或按周显示顾客的新增注册数和活跃数，活跃数来自操作日志中的成功登录记录
+func showUserGrowth() {
+	fmt.Print("请选择汇总方式（1. 按日 2. 按周，回车默认按日）：")
+	mode, label := growthByDay, "日期"
+	switch readLine() {
+	case "", "1":
+	case "2":
+		mode, label = growthByWeek, "周（起始周一）"
+	default:
+		fmt.Println(t("msg.invalid_choice"))
+		return
+	}
+	// 持有 logMu 避免读到写了一半的日志行，文件本身经 readDataFile 在 fileMu 保护下读取
+	logMu.Lock()
+	data, err := readDataFile(logFile)
+	logMu.Unlock()
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println("读取操作日志错误：", err)
+		return
+	}
+	rows, unknown := userGrowth(users, parseLoginEvents(string(data)), mode)
+	fmt.Println("----- 用户增长 -----")
+	if len(rows) == 0 {
+		fmt.Println("暂无注册或登录数据")
+	} else {
+		fmt.Printf("%s%s%s\n", padRight(label, 18), padRight("新增注册", 12), "活跃用户")
+		for _, row := range rows {
+			fmt.Printf("%s%s%d\n", padRight(row.Period, 18), padRight(strconv.Itoa(row.NewUsers), 12), row.ActiveUsers)
+		}
+	}
+	if unknown > 0 {
+		fmt.Printf("另有 %d 位老用户缺少注册时间，未计入新增注册数\n", unknown)
+	}
+}
+
+// spenderRank 是消费排行榜中的一行，并列的顾客名次相同
+type spenderRank struct {
+	Rank   int
+	UserID int
+	Total  float64
+}
+
+// defaultTopSpenders 为消费排行榜默认显示的名次数
+const defaultTopSpenders = 10
+
+// topSpenders 按未取消预订的累计金额从高到低返回前 n 名顾客。金额相同的顾客并列同一名次，
+// 其后的名次顺延（如 1、2、2、4）；第 n 名有并列时一并返回，因此结果可能多于 n 条。
+// 同名次内按用户 ID 升序排列，没有消费的顾客不参与排名
+func topSpenders(list []Booking, n int) []spenderRank {
+	totals := make(map[int]float64)
+	for _, booking := range list {
+		if booking.Status != bookingStatusCancelled {
+			totals[booking.UserID] += booking.TotalCost
+		}
+	}
+	var ranks []spenderRank
+	for userID, total := range totals {
+		if total > 0 {
+			ranks = append(ranks, spenderRank{UserID: userID, Total: total})
+		}
+	}
+	sort.Slice(ranks, func(i, j int) bool {
+		if ranks[i].Total != ranks[j].Total {
+			return ranks[i].Total > ranks[j].Total
+		}
+		return ranks[i].UserID < ranks[j].UserID
+	})
+	for i := range ranks {
+		// 金额按分比较，避免浮点累加误差把相同金额判为不同
+		if i > 0 && math.Round(ranks[i].Total*100) == math.Round(ranks[i-1].Total*100) {
+			ranks[i].Rank = ranks[i-1].Rank
+		} else {
+			ranks[i].Rank = i + 1
+		}
+	}
+	end := 0
+	for end < len(ranks) && ranks[end].Rank <= n {
+		end++
+	}
+	return ranks[:end]
+}
+
+// showTopSpenders 输入名次数并显示顾客消费排行榜
+func showTopSpenders() {
+	fmt.Printf("请输入要显示的名次数（回车默认前 %d 名）：", defaultTopSpenders)
+	n := defaultTopSpenders
+	if input := readLine(); input != "" {
+		value, err := strconv.Atoi(input)
+		if err != nil || value <= 0 {
+			fmt.Println("无效的名次数")
+			return
+		}
+		n = value
+	}
+	ranks := topSpenders(bookings, n)
+	if len(ranks) == 0 {
+		fmt.Println("暂无顾客消费记录")
+		return
+	}
+	fmt.Printf("----- 顾客消费排行榜（前 %d 名） -----\n", n)
+	for _, rank := range ranks {
+		fmt.Printf("第 %d 名  %s  累计消费 %.2f 元\n", rank.Rank, usernameOf(rank.UserID), rank.Total)
+	}
+}
+
+// occupancy 返回已订出的房间数和总房间数
+func occupancy() (booked, total int) {
+	for _, room := range rooms {
+		total += room.Total
+		booked += room.Total - room.Available
+	}
+	return booked, total
+}
+
+// ------------------------- 余额流水 ----------------------------
+
+// adjustBalance 是修改用户余额的唯一入口：校验变动金额合法且变动后余额不为负，
+// 校验失败时返回错误且不修改余额
+func adjustBalance(user *User, delta float64) error {
+	if math.IsNaN(delta) || math.IsInf(delta, 0) {
+		return errors.New("无效的金额")
+	}
+	newBalance := user.Balance + delta
+	if newBalance < 0 {
+		return fmt.Errorf("余额不能为负数（当前余额 %.2f，变动 %+.2f）", user.Balance, delta)
+	}
+	user.Balance = newBalance
+	return nil
+}
+
+// recordTransaction 记录一条余额流水并立即保存
+func recordTransaction(userID int, amount float64, txType, note string) {
+	transactions = append(transactions, Transaction{
+		ID:        getNextTransactionID(),
+		UserID:    userID,
+		Amount:    amount,
+		Type:      txType,
+		Note:      note,
+		CreatedAt: time.Now().Format(timeLayout),
+	})
+	saveTransactions()
+	logOperation(operatorName(), fmt.Sprintf("余额变动：用户 %d %+.2f（%s）", userID, amount, transactionTypeLabel(txType)), note)
+}
+
+// getNextTransactionID 获取下一个流水 ID（自动递增）
+func getNextTransactionID() int {
+	maxID := 0
+	for _, tx := range transactions {
+		if tx.ID > maxID {
+			maxID = tx.ID
+		}
+	}
+	return maxID + 1
+}
+
+// transactionsOfUser 返回某个用户的全部流水
+func transactionsOfUser(userID int) []Transaction {
+	var result []Transaction
+	for _, tx := range transactions {
+		if tx.UserID == userID {
+			result = append(result, tx)
+		}
+	}
+	return result
+}
+
+// printTransactions 按时间顺序打印流水列表
+func printTransactions(list []Transaction) {
+	if len(list) == 0 {
+		fmt.Println("暂无流水记录")
+		return
+	}
+	fmt.Println("----- 账单流水 -----")
+	for _, tx := range list {
+		fmt.Printf("流水号: %d, 用户: %s, 金额: %+.2f, 类型: %s, 时间: %s, 说明: %s\n",
+			tx.ID, usernameOf(tx.UserID), tx.Amount, transactionTypeLabel(tx.Type), tx.CreatedAt, tx.Note)
+	}
+}
+
+// transactionTypeLabel 返回流水类型的中文说明
+func transactionTypeLabel(txType string) string {
+	switch txType {
+	case transactionTypeInitial:
+		return "初始余额"
+	case transactionTypeBooking:
+		return "预订扣款"
+	case transactionTypeRefund:
+		return "退款"
+	case transactionTypeRecharge:
+		return "充值"
+	case transactionTypeAdjust:
+		return "管理员调整"
+	case transactionTypeFee:
+		return "取消手续费"
+	case transactionTypeTransfer:
+		return "转账"
+	default:
+		return txType
+	}
+}
+
+// ------------------------- 数据备份 ----------------------------
+
+// backupVersion 为备份包格式的版本号，恢复时只接受相同版本的备份包
+const backupVersion = 1
+
+// backupManifestName 为备份包中清单文件的名称
+const backupManifestName = "manifest.json"
+
+// backupFiles 为备份包包含的数据文件
+var backupFiles = []string{usersFile, roomsFile, bookingsFile, transactionsFile, settingsFile, priceHistoryFile, messagesFile, reviewsFile, couponsFile}
+
+// backupManifest 是备份包的清单，记录版本、创建时间以及每个数据文件的 sha256 摘要，用于恢复前校验完整性
+type backupManifest struct {
+	Version   int               `json:"version"`
+	CreatedAt string            `json:"created_at"`
+	Files     map[string]string `json:"files"` // 文件名 -> sha256 十六进制摘要
+}
+
+// backupMenu 管理员备份或恢复全部数据，返回 true 表示已恢复数据、需要重新登录
+func backupMenu(current *User) bool {
+	for {
+		fmt.Println(t("menu.backup"))
+		printOptions(t("menu.backup.create"), t("menu.backup.restore"), t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			defaultName := "backup_" + time.Now().Format("20060102_150405") + ".zip"
+			fmt.Printf("请输入备份文件名（回车默认为 %s）：", defaultName)
+			path := readLine()
+			if path == "" {
+				path = defaultName
+			}
+			err := createBackup(path)
+			logOperation(operatorName(), "备份数据到 "+path, resultOf(err))
+			if err != nil {
+				fmt.Println("备份数据错误：", err)
+				continue
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+			fmt.Printf("备份成功！共 %d 个数据文件，文件路径: %s\n", len(backupFiles), path)
+		case "2":
+			if restoreBackupMenu(current) {
+				return true
+			}
+		case "3":
+			return false
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// createBackup 保存当前数据后把所有数据文件和清单打包为 zip 文件写入 path
+func createBackup(path string) error {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	saveAllData()
+	manifest := backupManifest{Version: backupVersion, CreatedAt: time.Now().Format(timeLayout), Files: map[string]string{}}
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	now := time.Now()
+	add := func(name string, data []byte) error {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	for _, name := range backupFiles {
+		data, err := readDataFile(name)
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %v", name, err)
+		}
+		if err := add(name, data); err != nil {
+			return err
+		}
+		manifest.Files[name] = fileChecksum(data)
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := add(backupManifestName, manifestData); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// fileChecksum 返回文件内容的 sha256 十六进制摘要
+func fileChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// readBackup 读取并校验备份包：清单版本必须为 backupVersion，backupFiles 中的每个文件都必须存在、
+// 摘要与清单一致且为合法 JSON。校验通过时返回清单和各文件内容
+func readBackup(path string) (backupManifest, map[string][]byte, error) {
+	var manifest backupManifest
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return manifest, nil, fmt.Errorf("无法打开备份包: %v", err)
+	}
+	defer r.Close()
+	contents := make(map[string][]byte)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return manifest, nil, err
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return manifest, nil, fmt.Errorf("读取 %s 失败: %v", f.Name, err)
+		}
+		contents[f.Name] = data
+	}
+	manifestData, ok := contents[backupManifestName]
+	if !ok {
+		return manifest, nil, errors.New("备份包缺少清单文件，可能不是本系统生成的备份")
+	}
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return manifest, nil, fmt.Errorf("清单文件损坏: %v", err)
+	}
+	if manifest.Version != backupVersion {
+		return manifest, nil, fmt.Errorf("备份包版本为 %d，当前系统只支持版本 %d", manifest.Version, backupVersion)
+	}
+	files := make(map[string][]byte)
+	for _, name := range backupFiles {
+		data, ok := contents[name]
+		if !ok {
+			return manifest, nil, fmt.Errorf("备份包缺少数据文件 %s", name)
+		}
+		if fileChecksum(data) != manifest.Files[name] {
+			return manifest, nil, fmt.Errorf("数据文件 %s 的校验和不一致，备份包可能已损坏", name)
+		}
+		if !json.Valid(data) {
+			return manifest, nil, fmt.Errorf("数据文件 %s 不是合法的 JSON", name)
+		}
+		files[name] = data
+	}
+	return manifest, files, nil
+}
+
+// restoreBackupMenu 输入备份包路径，校验通过并经二次确认和管理员密码验证后覆盖当前数据并重新加载，
+// 返回是否已恢复
+func restoreBackupMenu(current *User) bool {
+	fmt.Print("请输入备份文件路径：")
+	path := readLine()
+	if path == "" {
+		fmt.Println("文件路径不能为空")
+		return false
+	}
+	manifest, files, err := readBackup(path)
+	if err != nil {
+		fmt.Println("备份包校验失败：", err)
+		return false
+	}
+	fmt.Printf("备份包校验通过：版本 %d，创建于 %s，包含 %d 个数据文件\n", manifest.Version, manifest.CreatedAt, len(files))
+	fmt.Print("恢复将覆盖当前所有数据且无法撤销，确定继续吗？(y/n): ")
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		fmt.Println("已取消恢复")
+		return false
+	}
+	if !confirmAdminPassword(current, "从备份恢复数据") {
+		return false
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	for _, name := range backupFiles {
+		if err := writeDataFile(name, files[name]); err != nil {
+			fmt.Printf("写入 %s 失败：%v，请检查数据目录后重新恢复\n", name, err)
+			logOperation(operatorName(), "从备份恢复数据 "+path, "失败："+err.Error())
+			return false
+		}
+	}
+	// json.Unmarshal 会复用切片中原有的元素，备份中省略的 omitempty 字段会保留恢复前的值，
+	// 因此重新加载前先清空内存中的数据
+	users, rooms, bookings, transactions = nil, nil, nil, nil
+	priceHistory, inbox, reviews, coupons = nil, nil, nil, nil
+	settings = Settings{}
+	holds = nil
+	loadUsers()
+	loadRooms()
+	loadBookings()
+	loadTransactions()
+	loadSettings()
+	loadPriceHistory()
+	loadMessages()
+	loadReviews()
+	loadCoupons()
+	undoStack = nil
+	logOperation(operatorName(), "从备份恢复数据 "+path, "成功")
+	fmt.Println("数据恢复成功，请重新登录")
+	return true
+}
+
+// ------------------------- 数据导出 ----------------------------
+
+// exportData 让管理员选择导出对象和文件名，把数据导出为 CSV 文件
+func exportData() {
+	fmt.Print("请选择导出对象（1. 用户 2. 房间 3. 预订）：")
+	var records [][]string
+	var defaultName string
+	switch readLine() {
+	case "1":
+		records, defaultName = userCSVRecords(), "users.csv"
+	case "2":
+		records, defaultName = roomCSVRecords(), "rooms.csv"
+	case "3":
+		records, defaultName = bookingCSVRecords(), "bookings.csv"
+	default:
+		fmt.Println("无效的导出选项")
+		return
+	}
+	fmt.Printf("请输入导出文件名（回车默认为 %s）：", defaultName)
+	path := readLine()
+	if path == "" {
+		path = defaultName
+	}
+	if err := writeCSV(path, records); err != nil {
+		fmt.Println("导出数据错误：", err)
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	fmt.Printf("导出成功！共 %d 条记录，文件路径: %s\n", len(records)-1, path)
+}
+
+// writeCSV 把记录写入 CSV 文件，含逗号、引号的字段由 encoding/csv 负责转义
+func writeCSV(path string, records [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// userCSVRecords 生成用户数据的 CSV 记录（不导出密码）
+func userCSVRecords() [][]string {
+	records := [][]string{{"ID", "用户名", "角色", "顾客类型", "余额", "已删除"}}
+	for _, user := range users {
+		records = append(records, []string{
+			strconv.Itoa(user.ID), user.Username, user.Role, user.CustomerType,
+			strconv.FormatFloat(user.Balance, 'f', 2, 64), strconv.FormatBool(user.Deleted),
+		})
+	}
+	return records
+}
+
+// roomCSVRecords 生成房间数据的 CSV 记录
+func roomCSVRecords() [][]string {
+	records := [][]string{{"ID", "类型", "价格", "总数", "剩余"}}
+	for _, room := range rooms {
+		records = append(records, []string{
+			strconv.Itoa(room.ID), room.Type, strconv.FormatFloat(room.Price, 'f', 2, 64),
+			strconv.Itoa(room.Total), strconv.Itoa(room.Available),
+		})
+	}
+	return records
+}
+
+// bookingCSVRecords 生成预订数据的 CSV 记录
+func bookingCSVRecords() [][]string {
+	records := [][]string{{"订单号", "用户ID", "用户名", "房间ID", "房间类型", "数量", "金额", "入住", "退房", "下单时间", "状态"}}
+	for _, booking := range bookings {
+		var roomIDs []string
+		for _, item := range bookingItems(booking) {
+			roomIDs = append(roomIDs, strconv.Itoa(item.RoomID))
+		}
+		records = append(records, []string{
+			bookingNo(booking), strconv.Itoa(booking.UserID), usernameOf(booking.UserID),
+			strings.Join(roomIDs, ";"), bookingRoomsLabel(booking), strconv.Itoa(booking.Quantity),
+			strconv.FormatFloat(booking.TotalCost, 'f', 2, 64), booking.CheckIn, booking.CheckOut,
+			booking.CreatedAt, bookingStatusText(booking),
+		})
+	}
+	return records
+}
+
+// ------------------------- 收据 ----------------------------
+
+// displayWidth 返回字符串在终端中的显示宽度，中文等全角字符按 2 计算
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if r >= 0x2E80 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// padRight 在字符串右侧补空格直到显示宽度达到 width
+func padRight(text string, width int) string {
+	if gap := width - displayWidth(text); gap > 0 {
+		return text + strings.Repeat(" ", gap)
+	}
+	return text
+}
+
+// bookingNights 根据订单的入住、退房日期计算夜数，日期缺失或无效时返回 0
+func bookingNights(booking Booking) int {
+	in, err := time.Parse(dateLayout, booking.CheckIn)
+	if err != nil {
+		return 0
+	}
+	out, err := time.Parse(dateLayout, booking.CheckOut)
+	if err != nil || !out.After(in) {
+		return 0
+	}
+	return int(out.Sub(in).Hours() / 24)
+}
+
+// receiptText 生成订单收据的文本内容
+func receiptText(booking Booking) string {
+	var b strings.Builder
+	line := strings.Repeat("=", 56)
+	row := func(label, value string) {
+		fmt.Fprintf(&b, "%s%s\n", padRight(label, 12), value)
+	}
+	fmt.Fprintln(&b, line)
+	fmt.Fprintln(&b, padRight("", 20)+"酒店预订收据")
+	fmt.Fprintln(&b, line)
+	row("订单号:", bookingNo(booking))
+	row("顾客:", usernameOf(booking.UserID))
+	row("下单时间:", booking.CreatedAt)
+	if nights := bookingNights(booking); nights > 0 {
+		row("入住日期:", fmt.Sprintf("%s 至 %s（%d 晚）", booking.CheckIn, booking.CheckOut, nights))
+	}
+	row("状态:", bookingStatusText(booking))
+	for i, noteLine := range wrapText(booking.Note, 44) {
+		if i == 0 {
+			row("备注:", noteLine)
+		} else {
+			row("", noteLine)
+		}
+	}
+	fmt.Fprintln(&b, strings.Repeat("-", 56))
+	fmt.Fprintf(&b, "%s%s%s%s\n", padRight("房型", 20), padRight("数量", 8), padRight("单价", 14), "实付")
+	original := 0.0
+	for _, item := range bookingItems(booking) {
+		unit := "-"
+		if item.UnitPrice > 0 {
+			unit = fmt.Sprintf("%.2f", item.UnitPrice)
+			if room := findRoomByID(item.RoomID); room != nil {
+				unit += priceUnitSuffix(*room)
+				original += roomCost(*room, item.UnitPrice, bookingNights(booking), item.Quantity)
+			}
+		}
+		fmt.Fprintf(&b, "%s%s%s%.2f\n", padRight(roomTypeName(item.RoomID), 20), padRight(strconv.Itoa(item.Quantity), 8),
+			padRight(unit, 14), item.Cost)
+	}
+	fmt.Fprintln(&b, strings.Repeat("-", 56))
+	if booking.Surcharge > 0 {
+		original += booking.Surcharge
+		row("假日加价:", fmt.Sprintf("+%.2f", booking.Surcharge))
+	}
+	if original > booking.TotalCost {
+		row("原价合计:", fmt.Sprintf("%.2f", original))
+		if member := original - booking.TotalCost - booking.CouponDiscount; member > 0.005 {
+			row("折扣优惠:", fmt.Sprintf("-%.2f", member))
+		}
+	}
+	if booking.CouponCode != "" {
+		row("优惠券:", fmt.Sprintf("%s -%.2f", booking.CouponCode, booking.CouponDiscount))
+	}
+	row("实付总额:", fmt.Sprintf("%.2f 元", booking.TotalCost))
+	fmt.Fprintln(&b, line)
+	return b.String()
+}
+
+// writeReceipt 把订单收据写入数据目录下的 receipt_<订单号>.txt，返回文件的绝对路径
+func writeReceipt(booking Booking) (string, error) {
+	name := fmt.Sprintf("receipt_%s.txt", bookingNo(booking))
+	if err := writeDataFile(name, []byte(receiptText(booking))); err != nil {
+		return "", err
+	}
+	path := dataPath(name)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path, nil
+}
+
+// offerReceipt 下单成功后询问顾客是否生成收据
+func offerReceipt(booking Booking) {
+	fmt.Print("是否生成收据文件？(y/n): ")
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		return
+	}
+	saveReceipt(booking)
+}
+
+// saveReceipt 生成收据并提示文件路径
+func saveReceipt(booking Booking) {
+	path, err := writeReceipt(booking)
+	if err != nil {
+		fmt.Println("生成收据错误：", err)
+		return
+	}
+	fmt.Printf("收据已生成: %s\n", path)
+}
+
+// ------------------------- 顾客功能 ----------------------------
+
+// lowBalanceThreshold 返回当前的余额提醒阈值：管理员未设置时取可预订房间的最低价格，无房间时为 0
+func lowBalanceThreshold() float64 {
+	if settings.LowBalanceThreshold > 0 {
+		return settings.LowBalanceThreshold
+	}
+	cheapest := 0.0
+	for _, room := range availableRooms() {
+		if price := computePrice(room); cheapest == 0 || price < cheapest {
+			cheapest = price
+		}
+	}
+	return cheapest
+}
+
+// lowBalanceThresholdLabel 返回余额提醒阈值的显示文字
+func lowBalanceThresholdLabel() string {
+	if settings.LowBalanceThreshold > 0 {
+		return fmt.Sprintf("%.2f 元", settings.LowBalanceThreshold)
+	}
+	return fmt.Sprintf("按最便宜房间价格，当前 %.2f 元", lowBalanceThreshold())
+}
+
+// lowBalanceReminder 在顾客余额低于提醒阈值时返回充值提醒，否则返回空字符串；
+// 建议充值金额为补足阈值所需的差额，按百元向上取整
+func lowBalanceReminder(customer User) string {
+	threshold := lowBalanceThreshold()
+	if threshold <= 0 || customer.Balance >= threshold {
+		return ""
+	}
+	suggest := math.Ceil((threshold-customer.Balance)/100) * 100
+	return fmt.Sprintf("[提醒] 您的当前余额 %.2f 元低于 %.2f 元，可能无法完成预订，建议充值至少 %.0f 元。",
+		customer.Balance, threshold, suggest)
+}
+
+// checkInReminderDays 为入住提醒的提前天数：入住日期在今天起该天数以内的有效预订会在登录时提醒
+const checkInReminderDays = 3
+
+// upcomingCheckIn 是一条入住提醒，DaysLeft 为距入住还有几天，0 表示今天入住
+type upcomingCheckIn struct {
+	Booking  Booking
+	DaysLeft int
+}
+
+// calendarDaysBetween 返回从 from 所在日期到 to 所在日期相差的天数，只比较日期部分，
+// 不受一天中的时刻和夏令时切换影响
+func calendarDaysBetween(from, to time.Time) int {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// upcomingCheckIns 返回顾客入住日期在 now 当天起 days 天以内、尚未入住的预订，按入住日期升序排列。
+// 入住日期按 now 所在时区解析；已过入住日期、已入住、已退房、已取消或没有入住日期的预订不提醒
+func upcomingCheckIns(list []Booking, userID int, now time.Time, days int) []upcomingCheckIn {
+	var result []upcomingCheckIn
+	for _, booking := range list {
+		if booking.UserID != userID || booking.Status != bookingStatusBooked {
+			continue
+		}
+		checkIn, err := time.ParseInLocation(dateLayout, booking.CheckIn, now.Location())
+		if err != nil {
+			continue
+		}
+		if left := calendarDaysBetween(now, checkIn); left >= 0 && left <= days {
+			result = append(result, upcomingCheckIn{Booking: booking, DaysLeft: left})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Booking.CheckIn < result[j].Booking.CheckIn
+	})
+	return result
+}
+
+// daysLeftLabel 把距入住的天数显示为“今天”“明天”或“还有 N 天”
+func daysLeftLabel(days int) string {
+	switch days {
+	case 0:
+		return "今天"
+	case 1:
+		return "明天"
+	default:
+		return fmt.Sprintf("还有 %d 天", days)
+	}
+}
+
+// printCheckInReminders 登录后列出即将入住的预订，没有时不输出
+func printCheckInReminders(customer User) {
+	list := upcomingCheckIns(bookings, customer.ID, time.Now(), checkInReminderDays)
+	if len(list) == 0 {
+		return
+	}
+	fmt.Printf("[提醒] 您有 %d 个预订将在 %d 天内入住：\n", len(list), checkInReminderDays)
+	for _, item := range list {
+		fmt.Printf("  订单 %s：%s，入住 %s（%s）\n", bookingNo(item.Booking), bookingRoomsLabel(item.Booking),
+			item.Booking.CheckIn, daysLeftLabel(item.DaysLeft))
+	}
+}
+
+// customerMenu 为顾客提供房间查询、预订、查看余额及充值的菜单
+func customerMenu(user *User) {
+	// 进入菜单时提醒一次，之后余额重新跌破阈值时再提醒，避免每次刷新菜单都重复提示
+	reminded := false
+	if unread := unreadMessageCount(user.ID); unread > 0 {
+		fmt.Printf("您有 %d 条未读消息，请在“我的消息”中查看\n", unread)
+	}
+	printCheckInReminders(*user)
+	for {
+		if reminder := lowBalanceReminder(*user); reminder == "" {
+			reminded = false
+		} else if !reminded {
+			fmt.Println(reminder)
+			reminded = true
+		}
+		fmt.Println("================================")
+		fmt.Println(t("menu.customer"))
+		printOptions(t("menu.customer.rooms"), t("menu.customer.book"), t("menu.customer.balance"), t("menu.customer.recharge"),
+			t("menu.customer.search"), t("menu.customer.filter"), t("menu.customer.bookings"), t("menu.customer.password"),
+			t("menu.customer.cancel"), t("menu.customer.statement"), t("menu.customer.room_detail"), t("menu.customer.points"),
+			t("menu.customer.modify"), t("menu.customer.close"), t("menu.customer.cart"), t("menu.customer.tags"),
+			t("menu.customer.favorites"), fmt.Sprintf(t("menu.customer.messages"), unreadMessageCount(user.ID)),
+			t("menu.customer.transfer"), t("menu.customer.review"),
+			t("menu.customer.recommend"), t("menu.logout"))
+		fmt.Print(t("prompt.choice"))
+		choice := readLine()
+		switch choice {
+		case "1":
+			listAvailableRooms(chooseRoomSort())
+		case "2":
+			bookRoom(user)
+		case "3":
+			fmt.Printf(t("msg.balance")+"\n", user.Balance)
+			if user.CustomerType == "member" {
+				tier := memberTierOf(*user)
+				fmt.Printf("会员等级: %s（%s），累计消费: %.2f\n", tier.Name, discountLabel(tier.DiscountRate), totalSpent(user.ID))
+			}
+		case "4":
+			rechargeBalance(user)
+		case "5":
+			searchRooms()
+		case "6":
+			filterRoomsByPriceMenu()
+		case "7":
+			listMyBookings(user)
+		case "8":
+			changePassword(user)
+		case "9":
+			cancelMyBooking(user)
+		case "10":
+			printTransactions(transactionsOfUser(user.ID))
+		case "11":
+			showRoomDetail()
+		case "12":
+			fmt.Printf("当前积分: %d（实付每满 %d 元累计 1 积分）\n", user.Points, yuanPerPoint)
+		case "13":
+			modifyMyBooking(user)
+		case "14":
+			if closeMyAccount(user) {
+				return
+			}
+		case "15":
+			cartBooking(user)
+		case "16":
+			filterRoomsByTagsMenu()
+		case "17":
+			favoritesMenu(user)
+		case "18":
+			showMyMessages(user)
+		case "19":
+			transferBalance(user)
+		case "20":
+			reviewRoomMenu(user)
+		case "21":
+			showRecommendations(user)
+		case "22":
+			fmt.Println(t("msg.logout"))
+			saveUsers() // 保存余额变动
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// bookRoom 实现顾客预订房间：检查房间剩余数量和余额，预订成功后扣款并更新房间状态
+func bookRoom(customer *User) {
+	if len(availableRooms()) == 0 {
+		fmt.Println("当前所有房间均已订满，暂无可预订的房间")
+		return
+	}
+	listAvailableRooms(roomSortByID)
+	fmt.Print("请输入入住人数（直接回车跳过）：")
+	guests := 0
+	if input := readLine(); input != "" {
+		n, err := strconv.Atoi(input)
+		if err != nil || n <= 0 {
+			fmt.Println("无效的入住人数")
+			return
+		}
+		guests = n
+		if recommended := roomsForGuests(availableRooms(), guests); len(recommended) > 0 {
+			fmt.Printf("----- 单间可住 %d 人及以上的推荐房型 -----\n", guests)
+			printRooms(recommended)
+		} else {
+			fmt.Printf("没有单间可住 %d 人的房型，可预订多间\n", guests)
+		}
+	}
+	fmt.Print("请输入要预订的房间ID：")
+	idStr := readLine()
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("无效的房间ID")
+		return
+	}
+	bookRoomByID(customer, id, guests)
+}
+
+// ------------------------- 站内消息 ----------------------------
+
+// sendMessage 给用户发送一条站内消息并立即保存
+func sendMessage(userID int, msgType, content string) {
+	maxID := 0
+	for _, msg := range inbox {
+		if msg.ID > maxID {
+			maxID = msg.ID
+		}
+	}
+	inbox = append(inbox, Message{
+		ID:        maxID + 1,
+		UserID:    userID,
+		Type:      msgType,
+		Content:   content,
+		CreatedAt: time.Now().Format(timeLayout),
+	})
+	saveMessages()
+}
+
+// messagesOfUser 返回用户的消息，按生成时间倒序排列
+func messagesOfUser(userID int) []Message {
+	var result []Message
+	for _, msg := range inbox {
+		if msg.UserID == userID {
+			result = append(result, msg)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	return result
+}
+
+// unreadMessageCount 返回用户的未读消息数
+func unreadMessageCount(userID int) int {
+	count := 0
+	for _, msg := range inbox {
+		if msg.UserID == userID && !msg.Read {
+			count++
+		}
+	}
+	return count
+}
+
+// messageTypeLabel 返回消息类型的中文说明
+func messageTypeLabel(msgType string) string {
+	switch msgType {
+	case messageTypeBooking:
+		return "预订成功"
+	case messageTypeCancel:
+		return "订单取消"
+	case messageTypeRefund:
+		return "退款到账"
+	default:
+		return msgType
+	}
+}
+
+// showMyMessages 分页显示顾客的消息（未读在前标注），查看后全部标记为已读
+func showMyMessages(customer *User) {
+	list := messagesOfUser(customer.ID)
+	if len(list) == 0 {
+		fmt.Println("暂无消息")
+		return
+	}
+	fmt.Printf("----- 我的消息（共 %d 条，未读 %d 条） -----\n", len(list), unreadMessageCount(customer.ID))
+	printPaged(len(list), func(i int) {
+		msg := list[i]
+		state := "已读"
+		if !msg.Read {
+			state = "未读"
+		}
+		fmt.Printf("[%s] %s 【%s】%s\n", state, msg.CreatedAt, messageTypeLabel(msg.Type), msg.Content)
+	})
+	for i := range inbox {
+		if inbox[i].UserID == customer.ID {
+			inbox[i].Read = true
+		}
+	}
+	saveMessages()
+}
+
+// ------------------------- 优惠券 ----------------------------
+
+// normalizeCouponCode 把输入的优惠码去掉首尾空白并转为大写
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// findCoupon 按优惠码查找优惠券（不区分大小写），找不到时返回 nil
+func findCoupon(code string) *Coupon {
+	code = normalizeCouponCode(code)
+	for i := range coupons {
+		if coupons[i].Code == code {
+			return &coupons[i]
+		}
+	}
+	return nil
+}
+
+// checkCoupon 校验顾客能否在 today（2006-01-02）使用该优惠券：须在有效期内、次数未用完且该顾客未用过
+func checkCoupon(coupon Coupon, userID int, today string) error {
+	if today < coupon.ValidFrom {
+		return fmt.Errorf("优惠券 %s 起才能使用", coupon.ValidFrom)
+	}
+	if today > coupon.ValidUntil {
+		return fmt.Errorf("优惠券已于 %s 过期", coupon.ValidUntil)
+	}
+	if coupon.UsedCount >= coupon.MaxUses {
+		return errors.New("优惠券已被领完")
+	}
+	for _, id := range coupon.UsedBy {
+		if id == userID {
+			return errors.New("您已使用过该优惠券")
+		}
+	}
+	return nil
+}
+
+// couponDiscount 计算优惠券对应付金额 amount 的抵扣额，按分四舍五入；
+// 抵扣额不会超过应付金额，因此立减金额大于订单金额时订单抵扣为 0 元而不会倒贴
+func couponDiscount(coupon Coupon, amount float64) float64 {
+	if amount <= 0 {
+		return 0
+	}
+	var discount float64
+	switch coupon.Type {
+	case couponTypeAmount:
+		discount = coupon.Value
+	case couponTypePercent:
+		discount = amount * coupon.Value / 100
+	}
+	discount = math.Round(discount*100) / 100
+	if discount > amount {
+		discount = amount
+	}
+	if discount < 0 {
+		discount = 0
+	}
+	return discount
+}
+
+// couponFor 查找并校验优惠码，返回优惠券和对 amount 的抵扣额；不修改优惠券的使用记录
+func couponFor(code string, userID int, amount float64, now time.Time) (*Coupon, float64, error) {
+	coupon := findCoupon(code)
+	if coupon == nil {
+		return nil, 0, errors.New("优惠码不存在")
+	}
+	if err := checkCoupon(*coupon, userID, now.Format(dateLayout)); err != nil {
+		return nil, 0, err
+	}
+	return coupon, couponDiscount(*coupon, amount), nil
+}
+
+// allocateDiscount 把优惠券抵扣额按各项实付金额的比例摊到每一项上，最后一项承担分摊的尾差，
+// 使各项 Cost 之和仍等于订单实付金额
+func allocateDiscount(items []BookingItem, discount float64) {
+	total := 0.0
+	for _, item := range items {
+		total += item.Cost
+	}
+	if total <= 0 || discount <= 0 {
+		return
+	}
+	remaining := discount
+	for i := range items {
+		share := math.Round(discount*items[i].Cost/total*100) / 100
+		if i == len(items)-1 {
+			share = remaining
+		}
+		items[i].Cost -= share
+		remaining -= share
+	}
+}
+
+// readCouponCode 读取可选的优惠码，直接回车表示不使用；优惠码无效时提示原因后重新输入
+func readCouponCode(userID int, amount float64) string {
+	for {
+		fmt.Print("请输入优惠码（直接回车跳过）：")
+		input := readLine()
+		if strings.TrimSpace(input) == "" {
+			return ""
+		}
+		coupon, discount, err := couponFor(input, userID, amount, time.Now())
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("优惠券可用，本单可抵扣 %.2f 元\n", discount)
+		return coupon.Code
+	}
+}
+
+// couponLabel 返回优惠券抵扣方式的显示文字，如“立减 20.00 元”“减免 15%”
+func couponLabel(coupon Coupon) string {
+	if coupon.Type == couponTypePercent {
+		return fmt.Sprintf("减免 %g%%", coupon.Value)
+	}
+	return fmt.Sprintf("立减 %.2f 元", coupon.Value)
+}
+
+// newCoupon 校验管理员输入并构造优惠券：优惠码不能为空或重复，立减金额须大于 0，
+// 折扣百分比须在 0 到 100 之间（不含 0 和 100），有效期的结束日期不能早于开始日期，使用次数须大于 0
+func newCoupon(code, couponType string, value float64, validFrom, validUntil string, maxUses int) (Coupon, error) {
+	code = normalizeCouponCode(code)
+	if code == "" {
+		return Coupon{}, errors.New("优惠码不能为空")
+	}
+	if strings.ContainsAny(code, " \t") {
+		return Coupon{}, errors.New("优惠码不能包含空白字符")
+	}
+	if findCoupon(code) != nil {
+		return Coupon{}, fmt.Errorf("优惠码 %s 已存在", code)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+		return Coupon{}, errors.New("优惠额度必须大于 0")
+	}
+	if couponType == couponTypePercent && value >= 100 {
+		return Coupon{}, errors.New("折扣百分比必须小于 100")
+	}
+	from, err := time.Parse(dateLayout, validFrom)
+	if err != nil {
+		return Coupon{}, errors.New("生效日期格式错误")
+	}
+	until, err := time.Parse(dateLayout, validUntil)
+	if err != nil {
+		return Coupon{}, errors.New("失效日期格式错误")
+	}
+	if until.Before(from) {
+		return Coupon{}, errors.New("失效日期不能早于生效日期")
+	}
+	if maxUses <= 0 {
+		return Coupon{}, errors.New("可使用次数必须大于 0")
+	}
+	return Coupon{
+		Code:       code,
+		Type:       couponType,
+		Value:      value,
+		ValidFrom:  from.Format(dateLayout),
+		ValidUntil: until.Format(dateLayout),
+		MaxUses:    maxUses,
+		CreatedAt:  time.Now().Format(timeLayout),
+	}, nil
+}
+
+// couponMenu 管理员查看和创建优惠券
+func couponMenu() {
+	for {
+		fmt.Println("--------- 优惠券管理 ---------")
+		fmt.Println("1. 查看优惠券")
+		fmt.Println("2. 创建优惠券")
+		fmt.Println("3. 返回")
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			if len(coupons) == 0 {
+				fmt.Println("暂无优惠券")
+				continue
+			}
+			today := time.Now().Format(dateLayout)
+			for _, coupon := range coupons {
+				state := "可用"
+				if err := checkCoupon(coupon, 0, today); err != nil {
+					state = err.Error()
+				}
+				fmt.Printf("优惠码: %s, %s, 有效期: %s 至 %s, 已用: %d/%d, 状态: %s\n", coupon.Code, couponLabel(coupon),
+					coupon.ValidFrom, coupon.ValidUntil, coupon.UsedCount, coupon.MaxUses, state)
+			}
+		case "2":
+			createCouponMenu()
+		case "3":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// createCouponMenu 读取优惠券的各项设置并创建
+func createCouponMenu() {
+	fmt.Print("请输入优惠码：")
+	code := readLine()
+	fmt.Print("请选择抵扣方式（1. 立减金额 2. 按百分比减免）：")
+	couponType := couponTypeAmount
+	switch readLine() {
+	case "1":
+	case "2":
+		couponType = couponTypePercent
+	default:
+		fmt.Println(t("msg.invalid_choice"))
+		return
+	}
+	if couponType == couponTypePercent {
+		fmt.Print("请输入减免百分比（如 20 表示减免 20%）：")
+	} else {
+		fmt.Print("请输入立减金额：")
+	}
+	value, err := strconv.ParseFloat(readLine(), 64)
+	if err != nil {
+		fmt.Println("无效的优惠额度")
+		return
+	}
+	today := time.Now().Format(dateLayout)
+	fmt.Printf("请输入生效日期（格式 %s，回车默认为今天）：", dateLayout)
+	validFrom := readLine()
+	if validFrom == "" {
+		validFrom = today
+	}
+	fmt.Printf("请输入失效日期（含当天，格式 %s）：", dateLayout)
+	validUntil := readLine()
+	fmt.Print("请输入可使用次数：")
+	maxUses, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的次数")
+		return
+	}
+	coupon, err := newCoupon(code, couponType, value, validFrom, validUntil, maxUses)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	coupons = append(coupons, coupon)
+	saveCoupons()
+	logOperation(operatorName(), fmt.Sprintf("创建优惠券 %s（%s，%d 次）", coupon.Code, couponLabel(coupon), coupon.MaxUses), "成功")
+	fmt.Printf("优惠券 %s 创建成功\n", coupon.Code)
+}
+
+// ------------------------- 房间推荐 ----------------------------
+
+// 推荐的数量和价位区间
+const (
+	recommendCount      = 3   // 每次推荐的房间数
+	recommendPriceRange = 0.3 // 价位相近的范围：与顾客历史均价相差不超过该比例
+)
+
+// bookingPreference 是从顾客历史预订中统计出的偏好
+type bookingPreference struct {
+	Categories map[string]int // 各标准房型累计预订的间数
+	AvgPrice   float64        // 成交单价的加权均价，缺少成交单价时为 0
+}
+
+// preferenceOf 统计顾客未取消预订中各标准房型的间数和成交均价，没有历史时 Categories 为空
+func preferenceOf(list []Booking, userID int) bookingPreference {
+	pref := bookingPreference{Categories: make(map[string]int)}
+	sum, count := 0.0, 0
+	for _, booking := range list {
+		if booking.UserID != userID || booking.Status == bookingStatusCancelled {
+			continue
+		}
+		for _, item := range bookingItems(booking) {
+			pref.Categories[roomCategoryOf(item.RoomID)] += item.Quantity
+			if item.UnitPrice > 0 {
+				sum += item.UnitPrice * float64(item.Quantity)
+				count += item.Quantity
+			}
+		}
+	}
+	if count > 0 {
+		pref.AvgPrice = sum / float64(count)
+	}
+	return pref
+}
+
+// favoriteCategory 返回预订间数最多的标准房型，间数相同时按房型名称排序取第一个
+func favoriteCategory(pref bookingPreference) string {
+	best, bestCount := "", 0
+	for category, count := range pref.Categories {
+		if count > bestCount || (count == bestCount && category < best) {
+			best, bestCount = category, count
+		}
+	}
+	return best
+}
+
+// priceNear 判断价格是否与顾客历史均价相近（相差不超过 recommendPriceRange）
+func priceNear(pref bookingPreference, price float64) bool {
+	return pref.AvgPrice > 0 && math.Abs(price-pref.AvgPrice) <= pref.AvgPrice*recommendPriceRange
+}
+
+// recommendRooms 为顾客推荐最多 n 个当前可预订的房间，并返回推荐理由。
+// 有历史预订时推荐订过的房型或价位相近的房间：订得越多的房型越靠前，同一房型内价格越接近历史均价越靠前；
+// 没有历史（或没有符合偏好的可订房间）时冷启动，推荐所有顾客预订间数最多的热门房间，
+// 系统还没有任何预订时按价格从低到高推荐
+func recommendRooms(bookingList []Booking, roomList []Room, userID int, n int) ([]Room, string) {
+	var available []Room
+	for _, room := range roomList {
+		if isRoomBookable(room) {
+			available = append(available, room)
+		}
+	}
+	pref := preferenceOf(bookingList, userID)
+	if len(pref.Categories) > 0 {
+		var matched []Room
+		for _, room := range available {
+			if pref.Categories[normalizeRoomType(room.Type)] > 0 || priceNear(pref, room.Price) {
+				matched = append(matched, room)
+			}
+		}
+		if len(matched) > 0 {
+			sort.SliceStable(matched, func(i, j int) bool {
+				ci, cj := pref.Categories[normalizeRoomType(matched[i].Type)], pref.Categories[normalizeRoomType(matched[j].Type)]
+				if ci != cj {
+					return ci > cj
+				}
+				return math.Abs(matched[i].Price-pref.AvgPrice) < math.Abs(matched[j].Price-pref.AvgPrice)
+			})
+			reason := fmt.Sprintf("根据您常订的房型「%s」", favoriteCategory(pref))
+			if pref.AvgPrice > 0 {
+				reason += fmt.Sprintf("和价位（约 %.0f 元）", pref.AvgPrice)
+			}
+			return firstRooms(matched, n), reason + "推荐"
+		}
+	}
+	popularity := make(map[int]int)
+	for _, booking := range bookingList {
+		if booking.Status == bookingStatusCancelled {
+			continue
+		}
+		for _, item := range bookingItems(booking) {
+			popularity[item.RoomID] += item.Quantity
+		}
+	}
+	sort.SliceStable(available, func(i, j int) bool {
+		pi, pj := popularity[available[i].ID], popularity[available[j].ID]
+		if pi != pj {
+			return pi > pj
+		}
+		return available[i].Price < available[j].Price
+	})
+	if len(popularity) == 0 {
+		return firstRooms(available, n), "暂无预订数据，按价格从低到高推荐"
+	}
+	return firstRooms(available, n), "为您推荐最热门的房间"
+}
+
+// firstRooms 返回列表的前 n 个房间
+func firstRooms(list []Room, n int) []Room {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
+
+// showRecommendations 显示为顾客推荐的可订房间及推荐理由
+func showRecommendations(customer *User) {
+	list, reason := recommendRooms(bookings, rooms, customer.ID, recommendCount)
+	if len(list) == 0 {
+		fmt.Println("当前所有房间均已订满，暂无可推荐的房间")
+		return
+	}
+	fmt.Println("----- 为你推荐 -----")
+	fmt.Println(reason)
+	printRooms(list)
+}
+
+// ------------------------- 房间评价 ----------------------------
+
+// 评价的取值范围和显示条数
+const (
+	minRating         = 1
+	maxRating         = 5
+	maxReviewLength   = 200 // 评价内容最多的字符数
+	latestReviewCount = 3   // 房间详情中显示的最新评价条数
+)
+
+// reviewTarget 是一个可评价的对象：某笔订单中的某个房间
+type reviewTarget struct {
+	Booking Booking
+	RoomID  int
+}
+
+// hasReviewed 判断某笔订单中的某个房间是否已被评价
+func hasReviewed(list []Review, bookingID, roomID int) bool {
+	for _, review := range list {
+		if review.BookingID == bookingID && review.RoomID == roomID {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewTargets 返回顾客可以评价的对象：未取消且入住日期已到（today 为 2006-01-02）的订单中尚未评价的房间，
+// 没有日期的旧订单视为已入住。只有预订过的房间才会出现，同一订单的同一房间评价后不再出现
+func reviewTargets(bookingList []Booking, reviewList []Review, userID int, today string) []reviewTarget {
+	var result []reviewTarget
+	for _, booking := range bookingList {
+		if booking.UserID != userID || booking.Status == bookingStatusCancelled {
+			continue
+		}
+		if booking.CheckIn != "" && booking.CheckIn > today {
+			continue
+		}
+		for _, item := range bookingItems(booking) {
+			if !hasReviewed(reviewList, booking.ID, item.RoomID) {
+				result = append(result, reviewTarget{Booking: booking, RoomID: item.RoomID})
+			}
+		}
+	}
+	return result
+}
+
+// validateReview 校验评分在 minRating 到 maxRating 之间、评价内容不超过 maxReviewLength 个字符
+func validateReview(rating int, comment string) error {
+	if rating < minRating || rating > maxRating {
+		return fmt.Errorf("评分必须在 %d 到 %d 之间", minRating, maxRating)
+	}
+	if length := len([]rune(comment)); length > maxReviewLength {
+		return fmt.Errorf("评价内容不能超过 %d 个字符（当前 %d 个）", maxReviewLength, length)
+	}
+	return nil
+}
+
+// performReview 提交评价的业务部分：再次确认该订单房间可评价后保存，不读写标准输入输出
+func performReview(customer *User, target reviewTarget, rating int, comment string) error {
+	if err := validateReview(rating, comment); err != nil {
+		return err
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	eligible := false
+	for _, candidate := range reviewTargets(bookings, reviews, customer.ID, time.Now().Format(dateLayout)) {
+		if candidate.Booking.ID == target.Booking.ID && candidate.RoomID == target.RoomID {
+			eligible = true
+			break
+		}
+	}
+	if !eligible {
+		return errors.New("该订单的房间不可评价或已评价过")
+	}
+	maxID := 0
+	for _, review := range reviews {
+		if review.ID > maxID {
+			maxID = review.ID
+		}
+	}
+	reviews = append(reviews, Review{
+		ID:        maxID + 1,
+		UserID:    customer.ID,
+		RoomID:    target.RoomID,
+		BookingID: target.Booking.ID,
+		Rating:    rating,
+		Comment:   comment,
+		CreatedAt: time.Now().Format(timeLayout),
+	})
+	saveReviews()
+	return nil
+}
+
+// reviewRoomMenu 列出顾客可评价的订单房间，选择后输入评分和评价内容
+func reviewRoomMenu(customer *User) {
+	targets := reviewTargets(bookings, reviews, customer.ID, time.Now().Format(dateLayout))
+	if len(targets) == 0 {
+		fmt.Println("暂无可评价的订单（入住日期到达后才能评价，每个订单的房间只能评价一次）")
+		return
+	}
+	fmt.Println("----- 可评价的订单 -----")
+	for i, target := range targets {
+		fmt.Printf("%d. 订单 %s，%s，入住 %s 至 %s\n", i+1, bookingNo(target.Booking), roomTypeName(target.RoomID),
+			target.Booking.CheckIn, target.Booking.CheckOut)
+	}
+	fmt.Print("请选择要评价的序号：")
+	index, err := strconv.Atoi(readLine())
+	if err != nil || index < 1 || index > len(targets) {
+		fmt.Println("无效的序号")
+		return
+	}
+	fmt.Printf("请输入评分（%d-%d）：", minRating, maxRating)
+	rating, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的评分")
+		return
+	}
+	fmt.Printf("请输入评价内容（最多 %d 字，可留空）：", maxReviewLength)
+	comment := strings.TrimSpace(readLine())
+	target := targets[index-1]
+	err = performReview(customer, target, rating, comment)
+	logOperation(operatorName(), fmt.Sprintf("评价订单 %s 的房间 %d（%d 分）", bookingNo(target.Booking), target.RoomID, rating), resultOf(err))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("评价成功，感谢您的反馈！")
+}
+
+// reviewsOfRoom 返回某房间的评价，按评价时间倒序排列
+func reviewsOfRoom(list []Review, roomID int) []Review {
+	var result []Review
+	for _, review := range list {
+		if review.RoomID == roomID {
+			result = append(result, review)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+	return result
+}
+
+// averageRating 计算评价列表的平均分，列表为空时返回 0
+func averageRating(list []Review) float64 {
+	if len(list) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, review := range list {
+		sum += review.Rating
+	}
+	return float64(sum) / float64(len(list))
+}
+
+// ratingStars 把评分显示为星级，如 ★★★★☆
+func ratingStars(rating int) string {
+	return strings.Repeat("★", rating) + strings.Repeat("☆", maxRating-rating)
+}
+
+// printRoomReviews 在房间详情中显示平均评分和最新的 latestReviewCount 条评价
+func printRoomReviews(roomID int) {
+	list := reviewsOfRoom(reviews, roomID)
+	if len(list) == 0 {
+		fmt.Println("    评分: 暂无评价")
+		return
+	}
+	fmt.Printf("    评分: %.1f / %d（共 %d 条评价）\n", averageRating(list), maxRating, len(list))
+	if len(list) > latestReviewCount {
+		list = list[:latestReviewCount]
+	}
+	for _, review := range list {
+		comment := review.Comment
+		if comment == "" {
+			comment = "（未填写评价内容）"
+		}
+		fmt.Printf("    %s %s %s：%s\n", ratingStars(review.Rating), review.CreatedAt, usernameOf(review.UserID), comment)
+		if review.Reply != "" {
+			fmt.Printf("      官方回复：%s\n", review.Reply)
+		}
+	}
+}
+
+// maxReplyLength 为管理员回复最多的字符数
+const maxReplyLength = 200
+
+// pendingReviews 返回尚未回复的评价，低分在前，同分时较早的评价在前
+func pendingReviews(list []Review) []Review {
+	var result []Review
+	for _, review := range list {
+		if review.Reply == "" {
+			result = append(result, review)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Rating != result[j].Rating {
+			return result[i].Rating < result[j].Rating
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// findReviewByID 按 ID 查找评价，找不到时返回 nil
+func findReviewByID(id int) *Review {
+	for i := range reviews {
+		if reviews[i].ID == id {
+			return &reviews[i]
+		}
+	}
+	return nil
+}
+
+// printReviewForAdmin 显示一条评价的完整信息，含评价人、房间和已有回复
+func printReviewForAdmin(review Review) {
+	comment := review.Comment
+	if comment == "" {
+		comment = "（未填写评价内容）"
+	}
+	fmt.Printf("评价ID: %d, %s, 房间: %s, 顾客: %s, 时间: %s\n", review.ID, ratingStars(review.Rating),
+		roomTypeName(review.RoomID), usernameOf(review.UserID), review.CreatedAt)
+	fmt.Printf("    内容: %s\n", comment)
+	if review.Reply != "" {
+		fmt.Printf("    官方回复（%s，%s）: %s\n", review.RepliedBy, review.RepliedAt, review.Reply)
+	}
+}
+
+// adminReviewManagement 管理员查看待回复评价（低分优先）和全部评价，并回复或修改回复
+func adminReviewManagement() {
+	for {
+		fmt.Println("--------- 评价管理 ---------")
+		fmt.Println("1. 查看待回复评价")
+		fmt.Println("2. 查看全部评价")
+		fmt.Println("3. 回复或修改回复")
+		fmt.Println("4. 返回")
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			list := pendingReviews(reviews)
+			if len(list) == 0 {
+				fmt.Println("没有待回复的评价")
+				continue
+			}
+			fmt.Printf("----- 待回复评价（共 %d 条，低分优先） -----\n", len(list))
+			printPaged(len(list), func(i int) {
+				printReviewForAdmin(list[i])
+			})
+		case "2":
+			if len(reviews) == 0 {
+				fmt.Println("暂无评价")
+				continue
+			}
+			// 复制一份再排序，不改变评价在数据文件中的顺序
+			list := append([]Review(nil), reviews...)
+			sort.SliceStable(list, func(i, j int) bool {
+				return list[i].ID > list[j].ID
+			})
+			printPaged(len(list), func(i int) {
+				printReviewForAdmin(list[i])
+			})
+		case "3":
+			replyToReview()
+		case "4":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// replyToReview 输入评价 ID 后添加回复，已有回复时显示原回复并可修改
+func replyToReview() {
+	fmt.Print("请输入评价ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的ID")
+		return
+	}
+	review := findReviewByID(id)
+	if review == nil {
+		fmt.Println("未找到该评价")
+		return
+	}
+	printReviewForAdmin(*review)
+	fmt.Printf("请输入回复内容（最多 %d 字，直接回车取消）：", maxReplyLength)
+	reply := strings.TrimSpace(readLine())
+	if reply == "" {
+		fmt.Println("已取消回复")
+		return
+	}
+	if length := len([]rune(reply)); length > maxReplyLength {
+		fmt.Printf("回复不能超过 %d 个字符（当前 %d 个）\n", maxReplyLength, length)
+		return
+	}
+	action := "回复"
+	if review.Reply != "" {
+		action = "修改回复"
+	}
+	review.Reply = reply
+	review.RepliedBy = operatorName()
+	review.RepliedAt = time.Now().Format(timeLayout)
+	saveReviews()
+	logOperation(operatorName(), fmt.Sprintf("%s评价 %d", action, review.ID), "成功")
+	fmt.Printf("%s成功\n", action)
+}
+
+// ------------------------- 收藏房间 ----------------------------
+
+// addFavorite 把房间加入顾客的收藏，房间不存在或已收藏时返回错误
+func addFavorite(customer *User, roomID int) error {
+	if findRoomByID(roomID) == nil {
+		return errors.New("未找到该房间")
+	}
+	if isFavorite(*customer, roomID) {
+		return errors.New("该房间已在收藏中")
+	}
+	customer.FavoriteRooms = append(customer.FavoriteRooms, roomID)
+	return nil
+}
+
+// isFavorite 判断房间是否在顾客的收藏中
+func isFavorite(customer User, roomID int) bool {
+	for _, id := range customer.FavoriteRooms {
+		if id == roomID {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFavorite 把房间移出顾客的收藏，返回该房间原先是否在收藏中
+func removeFavorite(customer *User, roomID int) bool {
+	for i, id := range customer.FavoriteRooms {
+		if id == roomID {
+			customer.FavoriteRooms = append(customer.FavoriteRooms[:i], customer.FavoriteRooms[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// pruneFavorites 移除收藏中已被删除的房间，返回被移除的房间 ID
+func pruneFavorites(customer *User) []int {
+	var kept, removed []int
+	for _, id := range customer.FavoriteRooms {
+		if findRoomByID(id) == nil {
+			removed = append(removed, id)
+		} else {
+			kept = append(kept, id)
+		}
+	}
+	customer.FavoriteRooms = kept
+	return removed
+}
+
+// favoritesMenu 顾客查看和管理收藏的房间，可从收藏直接发起预订；
+// 进入时自动清理已被删除的房间
+func favoritesMenu(customer *User) {
+	if removed := pruneFavorites(customer); len(removed) > 0 {
+		saveUsers()
+		fmt.Printf("已自动移除 %d 个失效收藏（房间已被删除）\n", len(removed))
+	}
+	for {
+		fmt.Println("--------- 我的收藏 ---------")
+		if len(customer.FavoriteRooms) == 0 {
+			fmt.Println("暂无收藏的房间")
+		}
+		for _, id := range customer.FavoriteRooms {
+			room := findRoomByID(id)
+			state := ""
+			if !isRoomBookable(*room) {
+				state = " [暂不可预订]"
+			}
+			fmt.Printf("ID: %d, 类型: %s, 价格: %.2f%s, 剩余: %d%s\n",
+				room.ID, room.Type, computePrice(*room), priceUnitSuffix(*room), room.Available, state)
+		}
+		printOptions("添加收藏", "移除收藏", "从收藏预订", t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			fmt.Print("请输入要收藏的房间ID：")
+			id, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的房间ID")
+				continue
+			}
+			if err := addFavorite(customer, id); err != nil {
+				fmt.Println(err)
+				continue
+			}
+			saveUsers()
+			fmt.Println("已加入收藏")
+		case "2":
+			fmt.Print("请输入要移除的房间ID：")
+			id, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的房间ID")
+				continue
+			}
+			if !removeFavorite(customer, id) {
+				fmt.Println("该房间不在收藏中")
+				continue
+			}
+			saveUsers()
+			fmt.Println("已移出收藏")
+		case "3":
+			if len(customer.FavoriteRooms) == 0 {
+				fmt.Println("暂无收藏的房间，请先添加收藏")
+				continue
+			}
+			fmt.Print("请输入要预订的收藏房间ID：")
+			id, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的房间ID")
+				continue
+			}
+			if !isFavorite(*customer, id) {
+				fmt.Println("该房间不在收藏中")
+				continue
+			}
+			bookRoomByID(customer, id, 0)
+		case "4":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// bookRoomByID 为顾客预订指定的房间：输入日期和数量，确认订单摘要后下单
+func bookRoomByID(customer *User, id int, guests int) {
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	if room.Disabled {
+		fmt.Println("该房间已下架，暂不接受预订")
+		return
+	}
+	if !isRoomBookable(*room) {
+		fmt.Println("该房间已订满，请选择其它房间")
+		return
+	}
+	fmt.Printf("选择的房间: %s, 单价: %.2f%s, 剩余数量: %d\n", room.Type, computePrice(*room), priceUnitSuffix(*room), room.Available)
+	if computePrice(*room) != room.Price {
+		fmt.Printf("该房型库存紧张，价格已由 %.2f 上浮 %.0f%%\n", room.Price, surgeRate*100)
+	}
+	fmt.Printf("请输入入住日期（格式 %s）：", dateLayout)
+	checkIn := readLine()
+	fmt.Printf("请输入退房日期（格式 %s）：", dateLayout)
+	checkOut := readLine()
+	nights, err := stayNights(checkIn, checkOut)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if alternative := offerAlternatives(*room, checkIn, checkOut, nights); alternative != nil {
+		room, id = alternative, alternative.ID
+		fmt.Printf("已改选房间: %s, 单价: %.2f%s\n", room.Type, computePrice(*room), priceUnitSuffix(*room))
+	}
+	if window, ok := maintenanceConflict(*room, checkIn, checkOut); ok {
+		fmt.Printf("该房间维护期为 %s，所选日期不可预订，请选择其它日期\n", maintenanceLabel(window))
+		return
+	}
+	tierBefore := memberTierOf(*customer).Name
+	note, noteRead := "", false
+	couponCode := ""
+	var booking Booking
+	var quantity int
+	for {
+		// 每次选择数量前重新读取房间的最新库存，其它顾客可能已在此期间下单
+		room = findRoomByID(id)
+		if room == nil {
+			fmt.Println("该房间已被删除")
+			return
+		}
+		availableOnDates := availableRoomsOn(room.ID, checkIn, checkOut)
+		if availableOnDates == 0 {
+			fmt.Println("该房间在所选日期内已订满，请选择其它房间或日期")
+			return
+		}
+		fmt.Printf("所选日期内剩余: %d 间\n", availableOnDates)
+		fmt.Print("请输入预订数量：")
+		quantityStr := readLine()
+		quantity, err = strconv.Atoi(quantityStr)
+		if err != nil || quantity <= 0 {
+			fmt.Println("无效的数量")
+			return
+		}
+		if quantity > availableOnDates {
+			fmt.Printf("所选日期内仅剩 %d 间，请重新输入数量\n", availableOnDates)
+			continue
+		}
+		if !roomFitsGuests(*room, guests, quantity) {
+			fmt.Printf("注意：%d 间%s最多可住 %d 人，少于入住人数 %d 人，仍要继续吗？(y/n): ",
+				quantity, room.Type, roomCapacity(*room)*quantity, guests)
+			if confirm := readLine(); confirm != "y" && confirm != "Y" {
+				continue
+			}
+		}
+		if err := checkBookingLimits(customer.ID, room.ID, quantity); err != nil {
+			fmt.Println(err)
+			return
+		}
+		// 扣款前展示订单摘要和费用明细，顾客确认后才真正下单
+		breakdown := computeCostBreakdown(*room, computePrice(*room), checkIn, nights, quantity, *customer)
+		totalCost := breakdown.Total
+		if !noteRead {
+			note, noteRead = readBookingNote(), true
+			couponCode = readCouponCode(customer.ID, totalCost)
+		}
+		discount := 0.0
+		if couponCode != "" {
+			// 数量可能已重新选择，按本次金额重新计算抵扣；期间优惠券失效时本单不再使用
+			if _, discount, err = couponFor(couponCode, customer.ID, totalCost, time.Now()); err != nil {
+				fmt.Printf("优惠券 %s 无法使用：%v\n", couponCode, err)
+				couponCode, discount = "", 0
+			}
+		}
+		fmt.Println("----- 订单摘要 -----")
+		fmt.Printf("房型: %s，数量: %d 间，入住 %s 至 %s，共 %d 晚\n", room.Type, quantity, checkIn, checkOut, nights)
+		for _, line := range costBreakdownLines(breakdown) {
+			fmt.Println(line)
+		}
+		if couponCode != "" {
+			totalCost = math.Round((totalCost-discount)*100) / 100
+			fmt.Printf("优惠券 %s：-%.2f\n", couponCode, discount)
+			fmt.Printf("券后应付：%.2f\n", totalCost)
+		}
+		if note != "" {
+			fmt.Println("备注: " + note)
+		}
+		fmt.Printf("预计剩余余额: %.2f\n", customer.Balance-totalCost)
+		if customer.Balance < totalCost {
+			fmt.Println("余额不足，无法预订")
+			return
+		}
+		items := []BookingItem{{RoomID: id, Quantity: quantity}}
+		hold, err := placeHold(customer.ID, items, checkIn, checkOut, time.Now())
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("请重新选择数量")
+			continue
+		}
+		printHold(hold)
+		fmt.Print("确认下单吗？(y/n): ")
+		if confirm := readLine(); confirm != "y" && confirm != "Y" {
+			releaseHold(customer.ID)
+			fmt.Println("已取消本次预订")
+			return
+		}
+		if !confirmNotDuplicate(customer, items, checkIn, checkOut) {
+			releaseHold(customer.ID)
+			fmt.Println("已取消本次预订")
+			return
+		}
+		warnHoldExpired(hold)
+		// performBooking 在锁内按最新库存再判断一次，库存不足时让顾客按最新剩余数量重新选择
+		booking, err = performBooking(customer, id, checkIn, checkOut, quantity, note, couponCode)
+		logOperation(operatorName(), fmt.Sprintf("预订房间 %d × %d（%s 至 %s）", id, quantity, checkIn, checkOut), resultOf(err))
+		if errors.Is(err, errStockShortage) {
+			fmt.Println("手慢了，库存已变化，请重新选择数量")
+			continue
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		break
+	}
+	originalCost := roomCost(*room, booking.UnitPrice, nights, quantity) + booking.Surcharge
+	fmt.Printf("入住 %s，退房 %s，共 %d 晚\n", checkIn, checkOut, nights)
+	fmt.Printf("预订成功！订单号: %s，原价 %.2f 元，折后价 %.2f 元，共扣款 %.2f 元，剩余余额: %.2f\n",
+		bookingNo(booking), originalCost, booking.TotalCost, booking.TotalCost, customer.Balance)
+	fmt.Printf("本单获得积分 %d，当前积分: %d\n", booking.Points, customer.Points)
+	if tier := memberTierOf(*customer); customer.CustomerType == "member" && tier.Name != tierBefore {
+		fmt.Printf("恭喜！您已晋升为%s，之后预订享受 %s优惠\n", tier.Name, discountLabel(tier.DiscountRate))
+	}
+	offerReceipt(booking)
+}
+
+// ------------------------- 替代房型对比 ----------------------------
+
+// 替代房型对比的参数
+const (
+	alternativeCount      = 3   // 最多列出的替代房型数
+	alternativePriceRatio = 0.1 // 每间住宿费用不超过所选房型的 1.1 倍视为同价位
+)
+
+// roomAlternative 是替代房型对比表中的一行，Cost 为按本次入住夜数计算的每间住宿费用（不含会员折扣）
+type roomAlternative struct {
+	Room      Room
+	Price     float64 // 当前成交单价，含动态定价上浮
+	Cost      float64
+	Remaining int // 所选日期内的剩余间数
+}
+
+// newRoomAlternative 按本次入住的日期、夜数和剩余间数生成对比行，Cost 含周末、节假日加价
+func newRoomAlternative(room Room, checkIn string, nights, remaining int) roomAlternative {
+	price := computePrice(room)
+	cost, _ := stayCost(room, price, checkIn, nights, 1)
+	return roomAlternative{Room: room, Price: price, Cost: cost, Remaining: remaining}
+}
+
+// alternativeRooms 从 list 中筛选 room 的替代房型：与 room 同一标准房型（未归类的房型不参与对比）、
+// 不是 room 本身、未下架、remaining 中所选日期的剩余不少于 1 间，且每间住宿费用不超过 room 的
+// 1+alternativePriceRatio 倍。按每间费用从低到高排列，费用相同时剩余多者在前，最多返回 n 个
+func alternativeRooms(list []Room, room Room, remaining map[int]int, checkIn string, nights, n int) []roomAlternative {
+	category := normalizeRoomType(room.Type)
+	if category == otherRoomType {
+		return nil
+	}
+	limit := newRoomAlternative(room, checkIn, nights, 0).Cost * (1 + alternativePriceRatio)
+	var result []roomAlternative
+	for _, candidate := range list {
+		if candidate.ID == room.ID || candidate.Disabled || remaining[candidate.ID] < 1 ||
+			normalizeRoomType(candidate.Type) != category {
+			continue
+		}
+		if alternative := newRoomAlternative(candidate, checkIn, nights, remaining[candidate.ID]); alternative.Cost <= limit {
+			result = append(result, alternative)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Cost != result[j].Cost {
+			return result[i].Cost < result[j].Cost
+		}
+		return result[i].Remaining > result[j].Remaining
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// alternativeReason 判断是否需要向顾客展示替代房型：所选房型 selected 在所选日期内的剩余为 0
+// 或低于总数的 lowStockRatio 时为库存紧张，有每间费用更低的替代房型时为价格偏高；都不满足时返回空字符串
+func alternativeReason(selected roomAlternative, alternatives []roomAlternative) string {
+	room := selected.Room
+	if selected.Remaining == 0 {
+		return "所选日期内已订满"
+	}
+	if room.Total > 0 && float64(selected.Remaining)/float64(room.Total) < lowStockRatio {
+		return "所选日期内库存紧张"
+	}
+	for _, alternative := range alternatives {
+		if alternative.Cost < selected.Cost {
+			return "有更实惠的同类房型"
+		}
+	}
+	return ""
+}
+
+// offerAlternatives 在所选房型库存紧张或价格偏高时列出同类替代房型的对比表，
+// 顾客输入房间 ID 可改选，返回改选的房间；不需要对比或顾客继续原房型时返回 nil
+func offerAlternatives(room Room, checkIn, checkOut string, nights int) *Room {
+	remaining := make(map[int]int, len(rooms))
+	for _, candidate := range rooms {
+		remaining[candidate.ID] = availableRoomsOn(candidate.ID, checkIn, checkOut)
+	}
+	selected := newRoomAlternative(room, checkIn, nights, remaining[room.ID])
+	alternatives := alternativeRooms(rooms, room, remaining, checkIn, nights, alternativeCount)
+	reason := alternativeReason(selected, alternatives)
+	if reason == "" || len(alternatives) == 0 {
+		return nil
+	}
+	fmt.Printf("%s，以下同类房型可供对比：\n", reason)
+	fmt.Println(padRight("房间ID", 8) + padRight("房型", 14) + padRight("价格", 14) + padRight("每间合计", 12) + "剩余")
+	printRow := func(alternative roomAlternative, mark string) {
+		fmt.Printf("%s%s%s%s%d%s\n", padRight(strconv.Itoa(alternative.Room.ID), 8), padRight(alternative.Room.Type, 14),
+			padRight(fmt.Sprintf("%.2f%s", alternative.Price, priceUnitSuffix(alternative.Room)), 14),
+			padRight(fmt.Sprintf("%.2f", alternative.Cost), 12), alternative.Remaining, mark)
+	}
+	printRow(selected, "（当前选择）")
+	for _, alternative := range alternatives {
+		printRow(alternative, "")
+	}
+	fmt.Print("输入房间ID改选，直接回车继续预订当前房型：")
+	input := readLine()
+	if input == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(input)
+	if err == nil {
+		for _, alternative := range alternatives {
+			if alternative.Room.ID == id {
+				return findRoomByID(id)
+			}
+		}
+	}
+	fmt.Println("无效的选择，继续预订当前房型")
+	return nil
+}
+
+// computePrice 返回房间当前的成交单价（按晚计费为每晚，按次计费为每次）：启用动态定价且剩余比例低于 lowStockRatio 时
+// 按 surgeRate 上浮，否则为原价
+func computePrice(room Room) float64 {
+	if settings.DynamicPricing && room.Total > 0 && float64(room.Available)/float64(room.Total) < lowStockRatio {
+		return room.Price * (1 + surgeRate)
+	}
+	return room.Price
+}
+
+// costBreakdown 是一笔预订的费用明细：单价 × 夜数 × 间数 = 小计，小计减去会员折扣得到应付
+type costBreakdown struct {
+	UnitPrice   float64
+	PriceSuffix string // 单价后缀，如“/晚”“/次”
+	Nights      int    // 计费夜数，按次计费时为 0
+	Quantity    int
+	Subtotal    float64 // 折扣前小计
+	DiscountTag string  // 折扣说明，无折扣时为“无”
+	Discount    float64 // 折扣减免金额
+	Total       float64 // 应付金额
+	// 有周末、节假日加价时，小计为 BaseCost（单价 × 夜数 × 间数）加上 Surcharges 中各项加价，
+	// 加价金额已乘以间数；无加价时 Surcharges 为空
+	BaseCost   float64
+	Surcharges []nightSurcharge
+}
+
+// computeCostBreakdown 按成交单价计算费用明细，应付金额与 bookingCost 的计算方式一致
+func computeCostBreakdown(room Room, unitPrice float64, checkIn string, nights, quantity int, customer User) costBreakdown {
+	subtotal, _ := stayCost(room, unitPrice, checkIn, nights, quantity)
+	total := discountedCost(customer, subtotal)
+	surcharges := staySurcharges(room, unitPrice, checkIn, nights, settings)
+	for i := range surcharges {
+		surcharges[i].Amount = math.Round(surcharges[i].Amount*float64(quantity)*100) / 100
+	}
+	breakdown := costBreakdown{
+		UnitPrice:   unitPrice,
+		PriceSuffix: priceUnitSuffix(room),
+		Nights:      nights,
+		Quantity:    quantity,
+		BaseCost:    roomCost(room, unitPrice, nights, quantity),
+		Surcharges:  surcharges,
+		Subtotal:    subtotal,
+		DiscountTag: discountDescription(customer),
+		Discount:    subtotal - total,
+		Total:       total,
+	}
+	if room.PricingUnit == pricingUnitStay {
+		breakdown.Nights = 0
+	}
+	return breakdown
+}
+
+// costBreakdownLines 把费用明细格式化为逐步计算的几行文字
+func costBreakdownLines(b costBreakdown) []string {
+	first := fmt.Sprintf("单价 %.2f%s × %d 晚 × %d 间 = 小计 %.2f", b.UnitPrice, b.PriceSuffix, b.Nights, b.Quantity, b.Subtotal)
+	if b.Nights == 0 {
+		first = fmt.Sprintf("单价 %.2f%s × %d 间 = 小计 %.2f", b.UnitPrice, b.PriceSuffix, b.Quantity, b.Subtotal)
+	}
+	lines := []string{first}
+	// 有周末、节假日加价时先列基础房费和各项加价，再给出小计
+	if len(b.Surcharges) > 0 {
+		lines = []string{fmt.Sprintf("单价 %.2f%s × %d 晚 × %d 间 = 基础房费 %.2f", b.UnitPrice, b.PriceSuffix, b.Nights, b.Quantity, b.BaseCost)}
+		for _, surcharge := range b.Surcharges {
+			lines = append(lines, fmt.Sprintf("%s加价（%d 晚 +%.0f%%）：+%.2f", surcharge.Label, surcharge.Nights, surcharge.Rate*100, surcharge.Amount))
+		}
+		lines = append(lines, fmt.Sprintf("小计：%.2f", b.Subtotal))
+	}
+	return append(lines,
+		fmt.Sprintf("会员折扣（%s）：-%.2f", b.DiscountTag, b.Discount),
+		fmt.Sprintf("应付：%.2f", b.Total),
+	)
+}
+
+// roomCost 按房间的计价单位计算折扣前的费用：按晚计费为 单价×夜数×数量，按次计费为 单价×数量
+func roomCost(room Room, unitPrice float64, nights, quantity int) float64 {
+	if room.PricingUnit == pricingUnitStay {
+		return unitPrice * float64(quantity)
+	}
+	return unitPrice * float64(nights) * float64(quantity)
+}
+
+// ------------------------- 周末与节假日定价 ----------------------------
+
+// maxSurchargeRate 为周末、节假日加价比例的上限（3 即最多加价 300%）
+const maxSurchargeRate = 3.0
+
+// isWeekendNight 判断入住日期为 date 的这一晚是否为周末夜，即周五、周六晚入住（次日为周六、周日）
+func isWeekendNight(date time.Time) bool {
+	return date.Weekday() == time.Friday || date.Weekday() == time.Saturday
+}
+
+// isHoliday 判断 date（格式为 2006-01-02）是否在节假日日期表中
+func isHoliday(date string, holidays []string) bool {
+	for _, holiday := range holidays {
+		if holiday == date {
+			return true
+		}
+	}
+	return false
+}
+
+// nightRate 返回入住日期为 date 的这一晚相对基础价的加价比例和类别：日期在节假日表中且设置了节假日加价时
+// 按节假日加价，否则周末夜按周末加价，两者不叠加；平日或未设置加价时返回 0 和空字符串
+func nightRate(date time.Time, s Settings) (float64, string) {
+	if s.HolidaySurcharge > 0 && isHoliday(date.Format(dateLayout), s.Holidays) {
+		return s.HolidaySurcharge, "节假日"
+	}
+	if s.WeekendSurcharge > 0 && isWeekendNight(date) {
+		return s.WeekendSurcharge, "周末"
+	}
+	return 0, ""
+}
+
+// nightPrice 返回入住日期为 date 的这一晚的单价：在 unitPrice 基础上按 nightRate 加价，保留两位小数
+func nightPrice(unitPrice float64, date time.Time, s Settings) float64 {
+	rate, _ := nightRate(date, s)
+	return math.Round(unitPrice*(1+rate)*100) / 100
+}
+
+// nightSurcharge 是一次入住中同一类加价夜晚（节假日或周末）的汇总
+type nightSurcharge struct {
+	Label  string  // 加价类别，"节假日" 或 "周末"
+	Rate   float64 // 加价比例
+	Nights int     // 该类别的夜数
+	Amount float64 // 每间房在这些夜晚的加价合计
+}
+
+// staySurcharges 从 checkIn 起逐晚判断 nights 晚中哪些夜晚需要加价，按类别汇总每间房相对 unitPrice 的加价，
+// 类别按首次出现的顺序排列；跨周末或跨节假日的多晚入住只对相应的夜晚加价。
+// 按次计费的房间不区分日期，入住日期无效时同样不加价，均返回 nil
+func staySurcharges(room Room, unitPrice float64, checkIn string, nights int, s Settings) []nightSurcharge {
+	if room.PricingUnit == pricingUnitStay {
+		return nil
+	}
+	in, err := time.Parse(dateLayout, checkIn)
+	if err != nil {
+		return nil
+	}
+	var result []nightSurcharge
+	for i := 0; i < nights; i++ {
+		day := in.AddDate(0, 0, i)
+		rate, label := nightRate(day, s)
+		if rate == 0 {
+			continue
+		}
+		extra := nightPrice(unitPrice, day, s) - unitPrice
+		found := false
+		for j := range result {
+			if result[j].Label == label {
+				result[j].Nights++
+				result[j].Amount = math.Round((result[j].Amount+extra)*100) / 100
+				found = true
+			}
+		}
+		if !found {
+			result = append(result, nightSurcharge{Label: label, Rate: rate, Nights: 1, Amount: math.Round(extra*100) / 100})
+		}
+	}
+	return result
+}
+
+// stayCost 返回按当前加价设置计算的折扣前住宿费用及其中的加价金额：
+// 基础房费见 roomCost，每间房逐晚加价后乘以间数，加价金额保留两位小数
+func stayCost(room Room, unitPrice float64, checkIn string, nights, quantity int) (float64, float64) {
+	surcharge := 0.0
+	for _, item := range staySurcharges(room, unitPrice, checkIn, nights, settings) {
+		surcharge += item.Amount
+	}
+	surcharge = math.Round(surcharge*float64(quantity)*100) / 100
+	return roomCost(room, unitPrice, nights, quantity) + surcharge, surcharge
+}
+
+// surchargeLabel 返回加价设置的显示文字，如“周末 +20%，节假日 +50%（3 天）”，都未设置时为“未启用”
+func surchargeLabel(s Settings) string {
+	var parts []string
+	if s.WeekendSurcharge > 0 {
+		parts = append(parts, fmt.Sprintf("周末 +%.0f%%", s.WeekendSurcharge*100))
+	}
+	if s.HolidaySurcharge > 0 {
+		parts = append(parts, fmt.Sprintf("节假日 +%.0f%%（%d 天）", s.HolidaySurcharge*100, len(s.Holidays)))
+	}
+	if len(parts) == 0 {
+		return "未启用"
+	}
+	return strings.Join(parts, "，")
+}
+
+// parseSurchargeRate 把管理员输入的百分数（如 20 表示加价 20%）解析为加价比例，须在 0 到 maxSurchargeRate 之间
+func parseSurchargeRate(input string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(input, "%")), 64)
+	if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+		return 0, errors.New("无效的百分比")
+	}
+	if percent < 0 || percent > maxSurchargeRate*100 {
+		return 0, fmt.Errorf("加价比例须在 0%% 到 %.0f%% 之间", maxSurchargeRate*100)
+	}
+	return percent / 100, nil
+}
+
+// surchargeMenu 管理员设置周末、节假日加价比例并维护节假日日期表，修改后立即保存
+func surchargeMenu() {
+	for {
+		fmt.Println("----- 周末/节假日加价 -----")
+		fmt.Printf("周末（周五、周六晚）加价: %.0f%%\n", settings.WeekendSurcharge*100)
+		fmt.Printf("节假日加价: %.0f%%（节假日与周末重合时按节假日加价，不叠加）\n", settings.HolidaySurcharge*100)
+		if len(settings.Holidays) == 0 {
+			fmt.Println("节假日: 暂无")
+		} else {
+			fmt.Println("节假日: " + strings.Join(settings.Holidays, "、"))
+		}
+		printOptions("设置周末加价比例", "设置节假日加价比例", "添加节假日", "删除节假日", t("menu.back"))
+		fmt.Print(t("prompt.choice"))
+		switch choice := readLine(); choice {
+		case "1", "2":
+			fmt.Print("请输入加价百分比（如 20 表示加价 20%，0 表示不加价）：")
+			rate, err := parseSurchargeRate(readLine())
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			target := "周末"
+			if choice == "1" {
+				settings.WeekendSurcharge = rate
+			} else {
+				settings.HolidaySurcharge = rate
+				target = "节假日"
+			}
+			saveSettings()
+			logOperation(operatorName(), fmt.Sprintf("设置%s加价 %.0f%%", target, rate*100), "成功")
+			fmt.Printf("%s加价已设置为 %.0f%%\n", target, rate*100)
+		case "3":
+			fmt.Printf("请输入节假日日期（格式 %s，多个用逗号分隔）：", dateLayout)
+			var added []string
+			for _, date := range parseFacilities(readLine()) {
+				if _, err := time.Parse(dateLayout, date); err != nil {
+					fmt.Printf("无效的日期：%s\n", date)
+					continue
+				}
+				if isHoliday(date, settings.Holidays) {
+					fmt.Printf("%s 已在节假日表中\n", date)
+					continue
+				}
+				settings.Holidays = append(settings.Holidays, date)
+				added = append(added, date)
+			}
+			if len(added) == 0 {
+				continue
+			}
+			sort.Strings(settings.Holidays)
+			saveSettings()
+			logOperation(operatorName(), "添加节假日 "+strings.Join(added, "、"), "成功")
+			fmt.Printf("已添加 %d 个节假日\n", len(added))
+		case "4":
+			fmt.Print("请输入要删除的节假日日期：")
+			date := strings.TrimSpace(readLine())
+			index := -1
+			for i, holiday := range settings.Holidays {
+				if holiday == date {
+					index = i
+				}
+			}
+			if index == -1 {
+				fmt.Println("节假日表中没有该日期")
+				continue
+			}
+			settings.Holidays = append(settings.Holidays[:index:index], settings.Holidays[index+1:]...)
+			saveSettings()
+			logOperation(operatorName(), "删除节假日 "+date, "成功")
+			fmt.Println("节假日已删除")
+		case "5":
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// cartBooking 购物车式下单：顾客先选定入住日期，再连续添加多个房间和数量，
+// 确认后通过 performCartBooking 一次性结算，任一项失败则整单不成交
+func cartBooking(customer *User) {
+	fmt.Printf("请输入入住日期（格式 %s）：", dateLayout)
+	checkIn := readLine()
+	fmt.Printf("请输入退房日期（格式 %s）：", dateLayout)
+	checkOut := readLine()
+	nights, err := stayNights(checkIn, checkOut)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var cart []BookingItem
+	for {
+		fmt.Println("--------- 购物车 ---------")
+		if len(cart) == 0 {
+			fmt.Println("购物车为空")
+		}
+		for i, item := range cart {
+			fmt.Printf("%d) %s × %d\n", i+1, roomTypeName(item.RoomID), item.Quantity)
+		}
+		printOptions("添加房间", "移除一项", "结算", "放弃下单")
+		fmt.Print(t("prompt.choice"))
+		switch readLine() {
+		case "1":
+			listAvailableRooms(roomSortByID)
+			fmt.Print("请输入要添加的房间ID：")
+			id, err := strconv.Atoi(readLine())
+			if err != nil {
+				fmt.Println("无效的房间ID")
+				continue
+			}
+			room := findRoomByID(id)
+			if room == nil || !isRoomBookable(*room) {
+				fmt.Println("未找到该房间或该房间已订满")
+				continue
+			}
+			fmt.Printf("所选日期内剩余: %d 间，请输入数量：", availableRoomsOn(room.ID, checkIn, checkOut))
+			quantity, err := strconv.Atoi(readLine())
+			if err != nil || quantity <= 0 {
+				fmt.Println("无效的数量")
+				continue
+			}
+			cart = append(cart, BookingItem{RoomID: id, Quantity: quantity})
+		case "2":
+			fmt.Print("请输入要移除的序号：")
+			index, err := strconv.Atoi(readLine())
+			if err != nil || index < 1 || index > len(cart) {
+				fmt.Println("无效的序号")
+				continue
+			}
+			cart = append(cart[:index-1], cart[index:]...)
+		case "3":
+			if len(cart) == 0 {
+				fmt.Println("购物车为空，请先添加房间")
+				continue
+			}
+			if confirmCart(customer, cart, checkIn, checkOut, nights) {
+				return
+			}
+		case "4":
+			fmt.Println("已放弃本次下单")
+			return
+		default:
+			fmt.Println(t("msg.invalid_choice"))
+		}
+	}
+}
+
+// ------------------------- 临时占位 ----------------------------
+
+// roomHoldDuration 为确认订单前临时占位的保留时长，超时未确认的占位自动释放
+const roomHoldDuration = 5 * time.Minute
+
+// roomHold 是顾客进入确认环节时对房间的临时占位，有效期内其他顾客不能订走这些房间。
+// 过期的占位在计算库存时直接忽略（惰性释放），并在下次占位、释放或下单时从 holds 中清理
+type roomHold struct {
+	UserID    int
+	Items     []BookingItem // 占位的房间及数量
+	CheckIn   string
+	CheckOut  string
+	ExpiresAt time.Time // 占位的过期时间
+}
+
+// heldRoomsOn 返回 list 中在 now 时仍有效的占位对房间某一晚占用的数量（退房当天不占用）
+func heldRoomsOn(list []roomHold, roomID int, date string, now time.Time) int {
+	count := 0
+	for _, hold := range list {
+		if !now.Before(hold.ExpiresAt) || date < hold.CheckIn || date >= hold.CheckOut {
+			continue
+		}
+		for _, item := range hold.Items {
+			if item.RoomID == roomID {
+				count += item.Quantity
+			}
+		}
+	}
+	return count
+}
+
+// pruneHolds 返回 list 中在 now 时仍有效、且不属于 userID 的占位；userID 为 0 时只去掉过期的占位
+func pruneHolds(list []roomHold, userID int, now time.Time) []roomHold {
+	var result []roomHold
+	for _, hold := range list {
+		if now.Before(hold.ExpiresAt) && hold.UserID != userID {
+			result = append(result, hold)
+		}
+	}
+	return result
+}
+
+// placeHold 为顾客占住 items 中的房间 roomHoldDuration，每位顾客同时只保留一个占位，原有占位先释放；
+// 任一房间在所选日期内的剩余（已扣除其他顾客的占位）不足时返回 errStockShortage，且不占位
+func placeHold(userID int, items []BookingItem, checkIn, checkOut string, now time.Time) (roomHold, error) {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	holds = pruneHolds(holds, userID, now)
+	for _, item := range items {
+		if remaining := availableRoomsOn(item.RoomID, checkIn, checkOut); item.Quantity > remaining {
+			return roomHold{}, fmt.Errorf("%w：%s在所选日期内仅剩 %d 间", errStockShortage, roomTypeName(item.RoomID), remaining)
+		}
+	}
+	hold := roomHold{
+		UserID:    userID,
+		Items:     append([]BookingItem(nil), items...),
+		CheckIn:   checkIn,
+		CheckOut:  checkOut,
+		ExpiresAt: now.Add(roomHoldDuration),
+	}
+	holds = append(holds, hold)
+	return hold, nil
+}
+
+// releaseHold 立即释放顾客的占位（如顾客放弃下单），并顺带清理已过期的占位
+func releaseHold(userID int) {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	holds = pruneHolds(holds, userID, time.Now())
+}
+
+// printHold 提示顾客占位的保留期限
+func printHold(hold roomHold) {
+	fmt.Printf("已为您保留所选房间至 %s，请在 %.0f 分钟内确认，超时将自动释放\n",
+		hold.ExpiresAt.Format(timeLayout), roomHoldDuration.Minutes())
+}
+
+// warnHoldExpired 在顾客确认时占位已过期的情况下提示将按最新库存下单
+func warnHoldExpired(hold roomHold) {
+	if !time.Now().Before(hold.ExpiresAt) {
+		fmt.Println("保留时间已过，占位已自动释放，将按最新库存尝试下单")
+	}
+}
+
+// confirmCart 展示购物车结算摘要，顾客确认后下单；返回 true 表示已下单成功
+func confirmCart(customer *User, cart []BookingItem, checkIn, checkOut string, nights int) bool {
+	note := readBookingNote()
+	fmt.Println("----- 订单摘要 -----")
+	fmt.Printf("入住 %s 至 %s，共 %d 晚，折扣: %s\n", checkIn, checkOut, nights, discountDescription(*customer))
+	total := 0.0
+	for _, item := range cart {
+		room := findRoomByID(item.RoomID)
+		if room == nil {
+			fmt.Printf("房间 %d 已被删除，请移除该项\n", item.RoomID)
+			return false
+		}
+		breakdown := computeCostBreakdown(*room, computePrice(*room), checkIn, nights, item.Quantity, *customer)
+		total += breakdown.Total
+		fmt.Printf("  %s × %d\n", room.Type, item.Quantity)
+		for _, line := range costBreakdownLines(breakdown) {
+			fmt.Println("    " + line)
+		}
+	}
+	couponCode := readCouponCode(customer.ID, total)
+	if couponCode != "" {
+		_, discount, _ := couponFor(couponCode, customer.ID, total, time.Now())
+		fmt.Printf("优惠券 %s：-%.2f\n", couponCode, discount)
+		total = math.Round((total-discount)*100) / 100
+	}
+	if note != "" {
+		fmt.Println("备注: " + note)
+	}
+	fmt.Printf("应付总额: %.2f，预计剩余余额: %.2f\n", total, customer.Balance-total)
+	hold, err := placeHold(customer.ID, cart, checkIn, checkOut, time.Now())
+	if err != nil {
+		fmt.Printf("无法下单：%v，请调整购物车\n", err)
+		return false
+	}
+	printHold(hold)
+	fmt.Print("确认下单吗？(y/n): ")
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		releaseHold(customer.ID)
+		fmt.Println("未下单，可继续修改购物车")
+		return false
+	}
+	if !confirmNotDuplicate(customer, cart, checkIn, checkOut) {
+		releaseHold(customer.ID)
+		fmt.Println("未下单，可继续修改购物车")
+		return false
+	}
+	warnHoldExpired(hold)
+	booking, err := performCartBooking(customer, checkIn, checkOut, cart, note, couponCode)
+	logOperation(operatorName(), fmt.Sprintf("购物车下单 %d 项（%s 至 %s）", len(cart), checkIn, checkOut), resultOf(err))
+	if err != nil {
+		fmt.Printf("下单失败，整单未成交：%v\n", err)
+		return false
+	}
+	fmt.Printf("预订成功！订单号: %s，房间: %s，共扣款 %.2f 元，剩余余额: %.2f\n",
+		bookingNo(booking), bookingRoomsLabel(booking), booking.TotalCost, customer.Balance)
+	fmt.Printf("本单获得积分 %d，当前积分: %d\n", booking.Points, customer.Points)
+	offerReceipt(booking)
+	return true
+}
+
+// duplicateBookingWindow 为重复提交的判定时长：同一顾客在该时长内再次提交相同内容的预订视为可能重复
+const duplicateBookingWindow = 10 * time.Second
+
+// roomQuantities 把预订项汇总为 房间 ID -> 间数，同一房间的多项合并计算
+func roomQuantities(items []BookingItem) map[int]int {
+	result := make(map[int]int)
+	for _, item := range items {
+		result[item.RoomID] += item.Quantity
+	}
+	return result
+}
+
+// recentDuplicateBooking 检查顾客最近的一笔预订是否与本次提交重复：该笔未取消、在 now 之前
+// duplicateBookingWindow 内下单，且入住日期、房间和各房间数量都与本次相同时返回该笔预订，否则返回 nil。
+// 只比较最近一笔，因此内容不同的连续预订不受影响
+func recentDuplicateBooking(list []Booking, userID int, items []BookingItem, checkIn, checkOut string, now time.Time) *Booking {
+	var latest *Booking
+	for i := range list {
+		if list[i].UserID == userID && (latest == nil || list[i].ID > latest.ID) {
+			latest = &list[i]
+		}
+	}
+	if latest == nil || latest.Status == bookingStatusCancelled || latest.CheckIn != checkIn || latest.CheckOut != checkOut {
+		return nil
+	}
+	// CreatedAt 按本地时间记录，需按同一时区解析后再比较
+	created, err := time.ParseInLocation(timeLayout, latest.CreatedAt, now.Location())
+	if err != nil {
+		return nil
+	}
+	if elapsed := now.Sub(created); elapsed < 0 || elapsed > duplicateBookingWindow {
+		return nil
+	}
+	previous, current := roomQuantities(bookingItems(*latest)), roomQuantities(items)
+	if len(previous) != len(current) {
+		return nil
+	}
+	for roomID, quantity := range current {
+		if previous[roomID] != quantity {
+			return nil
+		}
+	}
+	return latest
+}
+
+// confirmNotDuplicate 在疑似重复提交时请顾客再次确认，返回 false 表示顾客放弃本次下单
+func confirmNotDuplicate(customer *User, items []BookingItem, checkIn, checkOut string) bool {
+	now := time.Now()
+	previous := recentDuplicateBooking(bookings, customer.ID, items, checkIn, checkOut, now)
+	if previous == nil {
+		return true
+	}
+	fmt.Printf("您刚刚已提交过相同的预订（订单号 %s，%s），确定要再订一次吗？(y/n): ",
+		bookingNo(*previous), bookingRoomsLabel(*previous))
+	confirm := readLine()
+	return confirm == "y" || confirm == "Y"
+}
+
+// bookingCost 计算预订费用，返回按 computePrice 和周末、节假日加价计算的原价，以及按顾客会员等级折扣后的应付金额
+func bookingCost(room Room, checkIn string, nights, quantity int, customer User) (float64, float64) {
+	originalCost, _ := stayCost(room, computePrice(room), checkIn, nights, quantity)
+	return originalCost, discountedCost(customer, originalCost)
+}
+
+// performBooking 执行单个房间预订的业务部分，规则同 performCartBooking
+func performBooking(customer *User, roomID int, checkIn, checkOut string, quantity int, note, couponCode string) (Booking, error) {
+	return performCartBooking(customer, checkIn, checkOut, []BookingItem{{RoomID: roomID, Quantity: quantity}}, note, couponCode)
+}
+
+// errStockShortage 表示下单时房间的最新库存已不足，调用方可据此让顾客按最新库存重新选择
+var errStockShortage = errors.New("剩余房间不足")
+
+// performCartBooking 执行预订的业务部分：校验日期、数量限制、每一项的库存和总价是否超过余额，
+// 一次性扣款、扣减库存并生成一个预订记录；不读写标准输入输出，
+// 任一项校验失败时返回错误且不修改任何数据。items 只需填写 RoomID 和 Quantity，同一房间的多项会合并
+func performCartBooking(customer *User, checkIn, checkOut string, items []BookingItem, note, couponCode string) (Booking, error) {
+	if len(items) == 0 {
+		return Booking{}, errors.New("购物车为空")
+	}
+	if err := validateBookingNote(note); err != nil {
+		return Booking{}, err
+	}
+	nights, err := stayNights(checkIn, checkOut)
+	if err != nil {
+		return Booking{}, err
+	}
+	var merged []BookingItem
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return Booking{}, errors.New("无效的数量")
+		}
+		found := false
+		for i := range merged {
+			if merged[i].RoomID == item.RoomID {
+				merged[i].Quantity += item.Quantity
+				found = true
+			}
+		}
+		if !found {
+			merged = append(merged, BookingItem{RoomID: item.RoomID, Quantity: item.Quantity})
+		}
+	}
+	// 多项订单的错误信息带上房型，便于顾客定位是哪一项失败
+	itemError := func(roomID int, err error) error {
+		if len(merged) > 1 {
+			return fmt.Errorf("%s：%w", roomTypeName(roomID), err)
+		}
+		return err
+	}
+	// 从检查数量上限、库存到扣款写盘必须原子完成，否则并发预订可能超卖；
+	// 上限检查会统计已有预订，同样放在锁内，避免两笔并发订单都按旧数据通过
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	// 先释放顾客本人的占位，占住的房间转为本单使用，不再与自己的占位冲突
+	holds = pruneHolds(holds, customer.ID, time.Now())
+	for _, item := range merged {
+		if err := checkBookingLimits(customer.ID, item.RoomID, item.Quantity); err != nil {
+			return Booking{}, itemError(item.RoomID, err)
+		}
+	}
+	// 加锁后重新查找房间并计算库存，输入期间数据可能已被其它操作修改
+	totalCost, surcharge := 0.0, 0.0
+	for i := range merged {
+		item := &merged[i]
+		room := findRoomByID(item.RoomID)
+		if room == nil {
+			return Booking{}, itemError(item.RoomID, errors.New("该房间已被删除"))
+		}
+		if room.Disabled {
+			return Booking{}, itemError(item.RoomID, errors.New("该房间已下架，暂不接受预订"))
+		}
+		if window, ok := maintenanceConflict(*room, checkIn, checkOut); ok {
+			return Booking{}, itemError(item.RoomID, fmt.Errorf("所选日期与维护期 %s 冲突，暂不接受预订", maintenanceLabel(window)))
+		}
+		if item.Quantity > room.Available {
+			return Booking{}, itemError(item.RoomID, fmt.Errorf("%w：预订数量超过当前剩余的 %d 间", errStockShortage, room.Available))
+		}
+		if remaining := availableRoomsOn(room.ID, checkIn, checkOut); item.Quantity > remaining {
+			return Booking{}, itemError(item.RoomID, fmt.Errorf("%w：所选日期内仅剩 %d 间，请调整日期或数量", errStockShortage, remaining))
+		}
+		item.UnitPrice = computePrice(*room)
+		_, item.Cost = bookingCost(*room, checkIn, nights, item.Quantity, *customer)
+		_, itemSurcharge := stayCost(*room, item.UnitPrice, checkIn, nights, item.Quantity)
+		totalCost += item.Cost
+		surcharge += itemSurcharge
+	}
+	// 优惠券在会员折扣之后抵扣，同样在锁内校验，避免并发下单把次数用超
+	var coupon *Coupon
+	discount := 0.0
+	if couponCode != "" {
+		coupon, discount, err = couponFor(couponCode, customer.ID, totalCost, time.Now())
+		if err != nil {
+			return Booking{}, err
+		}
+		allocateDiscount(merged, discount)
+		totalCost = math.Round((totalCost-discount)*100) / 100
+	}
+	if customer.Balance < totalCost {
+		return Booking{}, errors.New("余额不足，无法预订")
+	}
+	// 扣减余额并更新房间剩余数量；adjustBalance 会再次确认扣款后余额不为负
+	if err := adjustBalance(customer, -totalCost); err != nil {
+		return Booking{}, err
+	}
+	quantity := 0
+	for _, item := range merged {
+		findRoomByID(item.RoomID).Available -= item.Quantity
+		quantity += item.Quantity
+	}
+	// 生成预订记录：单个房间沿用 RoomID、UnitPrice 字段，多个房间记录在 Items 中。
+	// 订单号在持有 dataMu 时生成，并发下单不会拿到相同的序号
+	now := time.Now()
+	booking := Booking{
+		ID:        getNextBookingID(),
+		OrderNo:   nextOrderNo(bookings, now),
+		UserID:    customer.ID,
+		Quantity:  quantity,
+		TotalCost: totalCost,
+		Status:    bookingStatusBooked,
+		CheckIn:   checkIn,
+		CheckOut:  checkOut,
+		CreatedAt: now.Format(timeLayout),
+		Points:    pointsForAmount(totalCost),
+		Note:      note,
+		Surcharge: surcharge,
+	}
+	if len(merged) == 1 {
+		booking.RoomID = merged[0].RoomID
+		booking.UnitPrice = merged[0].UnitPrice
+	} else {
+		booking.Items = merged
+	}
+	if coupon != nil {
+		booking.CouponCode = coupon.Code
+		booking.CouponDiscount = discount
+		coupon.UsedCount++
+		coupon.UsedBy = append(coupon.UsedBy, customer.ID)
+		saveCoupons()
+	}
+	adjustPoints(customer, booking.Points)
+	booking.UnitNumbers = assignUnits(bookings, booking)
+	bookings = append(bookings, booking)
+	upgradeMemberTier(customer)
+	saveUsers()
+	saveRooms()
+	saveBookings()
+	recordTransaction(customer.ID, -totalCost, transactionTypeBooking, fmt.Sprintf("订单 %s 预订扣款", bookingNo(booking)))
+	sendMessage(customer.ID, messageTypeBooking, fmt.Sprintf("预订成功！订单 %s：%s，%s 至 %s，扣款 %.2f 元",
+		bookingNo(booking), bookingRoomsLabel(booking), checkIn, checkOut, totalCost))
+	return booking, nil
+}
+
+// 预订数量上限
+const (
+	maxRoomsPerBooking = 5  // 单次预订最多的房间数
+	maxRoomsPerRoomID  = 10 // 单个顾客在同一房型上累计持有（未取消）的最多房间数
+)
+
+// checkBookingLimits 检查本次预订数量是否超过单次上限，以及加上该顾客在同一房型上
+// 已持有的预订（已预订或已入住）后是否超过累计上限
+func checkBookingLimits(userID, roomID, quantity int) error {
+	if quantity > maxRoomsPerBooking {
+		return fmt.Errorf("单次预订最多 %d 间", maxRoomsPerBooking)
+	}
+	held := 0
+	for _, booking := range bookings {
+		if booking.UserID == userID && bookingHoldsRoom(booking) {
+			held += bookingRoomQuantity(booking, roomID)
+		}
+	}
+	if held+quantity > maxRoomsPerRoomID {
+		return fmt.Errorf("您在该房型上已持有 %d 间，累计最多只能持有 %d 间", held, maxRoomsPerRoomID)
+	}
+	return nil
+}
+
+// availableRoomsOn 计算房间在 [checkIn, checkOut) 区间内真正可预订的数量：
+// 区间内有任意一晚处于维护期时为 0，否则逐晚统计与之重叠的未取消预订及未过期的临时占位占用的房间数，取占用最多的一晚
+func availableRoomsOn(roomID int, checkIn, checkOut string) int {
+	room := findRoomByID(roomID)
+	if room == nil {
+		return 0
+	}
+	if _, ok := maintenanceConflict(*room, checkIn, checkOut); ok {
+		return 0
+	}
+	in, err := time.Parse(dateLayout, checkIn)
+	if err != nil {
+		return 0
+	}
+	out, err := time.Parse(dateLayout, checkOut)
+	if err != nil {
+		return 0
+	}
+	// 先筛出与所查区间重叠的未取消预订，再逐晚统计
+	probe := Booking{CheckIn: checkIn, CheckOut: checkOut}
+	var overlapping []Booking
+	for _, booking := range bookings {
+		if booking.Status != bookingStatusCancelled && hasOverlap(booking, probe) {
+			overlapping = append(overlapping, booking)
+		}
+	}
+	now := time.Now()
+	peak := 0
+	for day := in; day.Before(out); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		occupied := heldRoomsOn(holds, roomID, date, now)
+		for _, booking := range overlapping {
+			if bookingCoversDate(booking, date) {
+				occupied += bookingRoomQuantity(booking, roomID)
+			}
+		}
+		if occupied > peak {
+			peak = occupied
+		}
+	}
+	if peak >= room.Total {
+		return 0
+	}
+	return room.Total - peak
+}
+
+// bookedRoomsOn 返回房间某一晚被未取消预订占用的数量，不考虑维护期
+func bookedRoomsOn(roomID int, date string) int {
+	occupied := 0
+	for _, booking := range bookings {
+		if booking.Status != bookingStatusCancelled && bookingCoversDate(booking, date) {
+			occupied += bookingRoomQuantity(booking, roomID)
+		}
+	}
+	return occupied
+}
+
+// bookingCoversDate 判断预订是否占用某一晚（退房当天不占用）；
+// 没有日期的旧预订视为占用所有日期
+func bookingCoversDate(booking Booking, date string) bool {
+	if booking.CheckIn == "" || booking.CheckOut == "" {
+		return true
+	}
+	return booking.CheckIn <= date && date < booking.CheckOut
+}
+
+// hasOverlap 判断两个预订的入住区间是否有共同占用的夜晚。区间按 [入住日, 退房日) 计算，
+// 退房当天不算占用，因此一笔的退房日等于另一笔的入住日时不冲突；
+// 任一方没有日期（旧数据）时视为占用所有日期，总是冲突
+func hasOverlap(a, b Booking) bool {
+	if a.CheckIn == "" || a.CheckOut == "" || b.CheckIn == "" || b.CheckOut == "" {
+		return true
+	}
+	return a.CheckIn < b.CheckOut && b.CheckIn < a.CheckOut
+}
+
+// findRoomByID 按 ID 查找房间，找不到时返回 nil
+func findRoomByID(id int) *Room {
+	for i := range rooms {
+		if rooms[i].ID == id {
+			return &rooms[i]
+		}
+	}
+	return nil
+}
+
+// stayNights 校验入住、退房日期并返回入住天数：日期格式须合法，
+// 入住日期不能早于今天，退房日期必须晚于入住日期
+func stayNights(checkIn, checkOut string) (int, error) {
+	in, err := time.Parse(dateLayout, checkIn)
+	if err != nil {
+		return 0, errors.New("无效的入住日期格式")
+	}
+	out, err := time.Parse(dateLayout, checkOut)
+	if err != nil {
+		return 0, errors.New("无效的退房日期格式")
+	}
+	if checkIn < time.Now().Format(dateLayout) {
+		return 0, errors.New("入住日期不能早于今天")
+	}
+	if !out.After(in) {
+		return 0, errors.New("退房日期必须晚于入住日期")
+	}
+	return int(out.Sub(in).Hours() / 24), nil
+}
+
+// discountDescription 返回顾客预订可享受折扣的说明，普通账号为"无"
+func discountDescription(customer User) string {
+	if customer.CustomerType != "member" {
+		return "无"
+	}
+	tier := memberTierOf(customer)
+	return tier.Name + " " + discountLabel(tier.DiscountRate)
+}
+
+// discountedCost 根据顾客类型计算折后金额：会员按所在等级的折扣率打折，普通账号全价
+func discountedCost(customer User, cost float64) float64 {
+	if customer.CustomerType == "member" {
+		return cost * memberTierOf(customer).DiscountRate
+	}
+	return cost
+}
+
+// memberTierOf 返回顾客当前的会员等级，未记录或名称无效时视为最低等级
+func memberTierOf(customer User) memberTier {
+	for _, tier := range memberTiers {
+		if tier.Name == customer.MemberTier {
+			return tier
+		}
+	}
+	return memberTiers[0]
+}
+
+// discountLabel 把折扣率格式化为中文习惯的"几折"，如 0.85 显示为 8.5折
+func discountLabel(rate float64) string {
+	return strconv.FormatFloat(math.Round(rate*100)/10, 'f', -1, 64) + "折"
+}
+
+// tierForSpent 返回累计消费额可达到的最高会员等级
+func tierForSpent(spent float64) memberTier {
+	result := memberTiers[0]
+	for _, tier := range memberTiers {
+		if spent >= tier.MinSpent {
+			result = tier
+		}
+	}
+	return result
+}
+
+// totalSpent 统计顾客未取消预订的实付总额，作为会员等级的累计消费依据
+func totalSpent(userID int) float64 {
+	var sum float64
+	for _, b := range bookings {
+		if b.UserID == userID && b.Status != bookingStatusCancelled {
+			sum += b.TotalCost
+		}
+	}
+	return sum
+}
+
+// upgradeMemberTier 按累计消费检查会员是否可以晋升，只升不降；返回是否发生了升级
+func upgradeMemberTier(customer *User) bool {
+	if customer.CustomerType != "member" {
+		return false
+	}
+	current := memberTierOf(*customer)
+	next := tierForSpent(totalSpent(customer.ID))
+	if next.MinSpent <= current.MinSpent {
+		return false
+	}
+	customer.MemberTier = next.Name
+	return true
+}
+
+// pointsForAmount 按 yuanPerPoint 计算实付金额可获得的积分，向下取整
+func pointsForAmount(amount float64) int {
+	if amount <= 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0
+	}
+	return int(amount / yuanPerPoint)
+}
+
+// adjustPoints 增减用户积分，扣减时积分最低为 0
+func adjustPoints(user *User, delta int) {
+	user.Points += delta
+	if user.Points < 0 {
+		user.Points = 0
+	}
+}
+
+// rechargeBalance 顾客为自己的账户充值，金额必须为正数
+func rechargeBalance(customer *User) {
+	fmt.Print("请输入充值金额：")
+	amountStr := readLine()
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		fmt.Println("无效的充值金额，请输入大于 0 的数字")
+		return
+	}
+	before := customer.Balance
+	if err := performRecharge(customer, amount); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("充值成功！充值前余额: %.2f，充值后余额: %.2f\n", before, customer.Balance)
+}
+
+// performRecharge 执行充值的业务部分：校验金额、入账并记录流水，不读写标准输入输出
+func performRecharge(customer *User, amount float64) error {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0 {
+		return errors.New("无效的充值金额，请输入大于 0 的数字")
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	if err := adjustBalance(customer, amount); err != nil {
+		return err
+	}
+	saveUsers()
+	recordTransaction(customer.ID, amount, transactionTypeRecharge, "顾客自助充值")
+	return nil
+}
+
+// transferBalance 顾客把余额转给另一位顾客，确认金额和收款人后执行转账
+func transferBalance(customer *User) {
+	fmt.Print("请输入收款人用户名：")
+	toName := readLine()
+	fmt.Print("请输入转账金额：")
+	amount, err := strconv.ParseFloat(readLine(), 64)
+	if err != nil {
+		fmt.Println("无效的转账金额，请输入大于 0 的数字")
+		return
+	}
+	fmt.Printf("确认向 %s 转账 %.2f 元吗？(y/n): ", toName, amount)
+	if confirm := readLine(); confirm != "y" && confirm != "Y" {
+		fmt.Println("已取消转账")
+		return
+	}
+	err = performTransfer(customer, toName, amount)
+	logOperation(operatorName(), fmt.Sprintf("转账给 %s %.2f 元", toName, amount), resultOf(err))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("转账成功！当前余额: %.2f\n", customer.Balance)
+}
+
+// performTransfer 执行转账的业务部分：校验金额和收款人后从转出方扣款、给收款方加款，
+// 两边余额在同一次加锁内修改并一起保存，双方各记一条流水；不读写标准输入输出
+func performTransfer(from *User, toName string, amount float64) error {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0 {
+		return errors.New("无效的转账金额，请输入大于 0 的数字")
+	}
+	if cents := amount * 100; math.Abs(cents-math.Round(cents)) > 1e-6 {
+		return errors.New("转账金额最多保留两位小数")
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	var to *User
+	for i := range users {
+		if !users[i].Deleted && users[i].Username == toName {
+			to = &users[i]
+			break
+		}
+	}
+	if to == nil {
+		return fmt.Errorf("收款人 %s 不存在", toName)
+	}
+	if to.ID == from.ID {
+		return errors.New("不能转账给自己")
+	}
+	if to.Role != "customer" {
+		return errors.New("只能转账给顾客账户")
+	}
+	if from.Balance < amount {
+		return fmt.Errorf("余额不足，当前余额 %.2f", from.Balance)
+	}
+	if err := adjustBalance(from, -amount); err != nil {
+		return err
+	}
+	if err := adjustBalance(to, amount); err != nil {
+		// 收款方入账失败时退回转出方的扣款，保证两边要么都改要么都不改
+		from.Balance += amount
+		return err
+	}
+	saveUsers()
+	recordTransaction(from.ID, -amount, transactionTypeTransfer, "转账给 "+to.Username)
+	recordTransaction(to.ID, amount, transactionTypeTransfer, "收到 "+from.Username+" 的转账")
+	return nil
+}
+
+// filterRoomsByPriceMenu 让顾客输入价格区间（留空代表不限）并列出区间内可预订的房间
+func filterRoomsByPriceMenu() {
+	fmt.Print("请输入最低价（回车表示不限）：")
+	min, ok := parseOptionalPrice(readLine(), 0)
+	if !ok {
+		fmt.Println("无效的价格输入")
+		return
+	}
+	fmt.Print("请输入最高价（回车表示不限）：")
+	max, ok := parseOptionalPrice(readLine(), math.Inf(1))
+	if !ok {
+		fmt.Println("无效的价格输入")
+		return
+	}
+	if min > max {
+		fmt.Println("最低价不能高于最高价")
+		return
+	}
+	result := filterRoomsByPrice(min, max)
+	if len(result) == 0 {
+		fmt.Println("该价格区间内暂无可预订的房间")
+		return
+	}
+	fmt.Println("----- 筛选结果 -----")
+	printRooms(result)
+}
+
+// 标签筛选的匹配模式
+const (
+	tagMatchAny = "any" // 匹配任一标签
+	tagMatchAll = "all" // 匹配全部标签
+)
+
+// parseTags 按与设施列表相同的分隔规则解析标签，忽略大小写去除重复项
+func parseTags(input string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range parseFacilities(input) {
+		key := strings.ToLower(tag)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// roomHasTag 判断房间是否带有指定标签，忽略大小写
+func roomHasTag(room Room, tag string) bool {
+	for _, own := range room.Tags {
+		if strings.EqualFold(own, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRoomsByTags 按标签筛选房间：tagMatchAny 时房间带有任一标签即匹配，tagMatchAll 时需带有全部标签；
+// tags 为空时不筛选，返回 list 本身
+func filterRoomsByTags(list []Room, tags []string, mode string) []Room {
+	if len(tags) == 0 {
+		return list
+	}
+	var result []Room
+	for _, room := range list {
+		matched := 0
+		for _, tag := range tags {
+			if roomHasTag(room, tag) {
+				matched++
+			}
+		}
+		if (mode == tagMatchAll && matched == len(tags)) || (mode != tagMatchAll && matched > 0) {
+			result = append(result, room)
+		}
+	}
+	return result
+}
+
+// filterRoomsByTagsMenu 交互式地输入标签和匹配模式，显示匹配的可预订房间
+func filterRoomsByTagsMenu() {
+	fmt.Print("请输入标签，用逗号分隔（如 海景,亲子）：")
+	tags := parseTags(readLine())
+	if len(tags) == 0 {
+		fmt.Println("标签不能为空")
+		return
+	}
+	mode := tagMatchAny
+	if len(tags) > 1 {
+		fmt.Print("请选择匹配方式（1. 匹配任一标签 2. 匹配全部标签，回车默认任一）：")
+		switch readLine() {
+		case "", "1":
+		case "2":
+			mode = tagMatchAll
+		default:
+			fmt.Println("无效的匹配方式")
+			return
+		}
+	}
+	result := filterRoomsByTags(availableRooms(), tags, mode)
+	if len(result) == 0 {
+		fmt.Printf("暂无带有标签“%s”的可预订房间\n", strings.Join(tags, "、"))
+		return
+	}
+	fmt.Println("----- 筛选结果 -----")
+	printRooms(result)
+}
+
+// parseOptionalPrice 解析价格输入，留空时返回默认值；负数或非数字视为无效
+func parseOptionalPrice(input string, def float64) (float64, bool) {
+	if input == "" {
+		return def, true
+	}
+	price, err := strconv.ParseFloat(input, 64)
+	if err != nil || math.IsNaN(price) || price < 0 {
+		return 0, false
+	}
+	return price, true
+}
+
+// changePassword 顾客自助修改密码：先验证旧密码，再两次输入新密码确认
+func changePassword(user *User) {
+	fmt.Print("请输入旧密码：")
+	oldPassword := readPassword()
+	if !checkPassword(user.Password, oldPassword) {
+		fmt.Println("旧密码错误")
+		return
+	}
+	newPassword, ok := promptNewPassword(oldPassword)
+	if !ok {
+		return
+	}
+	user.Password = hashPassword(newPassword)
+	saveUsers()
+	logOperation(operatorName(), "修改密码", "成功")
+	fmt.Println("密码修改成功")
+}
+
+// promptNewPassword 输入并二次确认新密码，新密码需与 oldPassword 不同且通过强度校验；
+// 校验失败时打印原因并返回 false
+func promptNewPassword(oldPassword string) (string, bool) {
+	fmt.Print("请输入新密码：")
+	newPassword := readPassword()
+	if newPassword == oldPassword {
+		fmt.Println("新密码不能与旧密码相同")
+		return "", false
+	}
+	if err := validatePassword(newPassword); err != nil {
+		fmt.Println(err)
+		return "", false
+	}
+	fmt.Print("请再次输入新密码：")
+	confirm := readPassword()
+	if confirm != newPassword {
+		fmt.Println("两次输入的新密码不一致")
+		return "", false
+	}
+	return newPassword, true
+}
+
+// ensureAdminPasswordChanged 在管理员仍使用默认密码时强制其修改密码，
+// 修改成功或无需修改时返回 true；放弃修改时返回 false，不进入管理菜单
+func ensureAdminPasswordChanged(admin *User) bool {
+	if !checkPassword(admin.Password, defaultAdminPassword) {
+		return true
+	}
+	fmt.Println("您正在使用默认管理员密码，存在安全风险，必须修改密码后才能进入管理菜单。")
+	for {
+		newPassword, ok := promptNewPassword(defaultAdminPassword)
+		if ok {
+			admin.Password = hashPassword(newPassword)
+			saveUsers()
+			logOperation(admin.Username, "修改默认管理员密码", "成功")
+			fmt.Println("密码修改成功，请牢记新密码")
+			return true
+		}
+		fmt.Print("是否重新输入新密码？(y/n): ")
+		if confirm := readLine(); confirm != "y" && confirm != "Y" {
+			logOperation(admin.Username, "修改默认管理员密码", "失败：已放弃，未进入管理菜单")
+			fmt.Println("未修改默认密码，已返回主菜单")
+			return false
+		}
+	}
+}
+
+// showRoomDetail 输入房间 ID 后显示该房间的完整信息
+func showRoomDetail() {
+	fmt.Print("请输入房间ID：")
+	id, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的房间ID")
+		return
+	}
+	room := findRoomByID(id)
+	if room == nil {
+		fmt.Println("未找到该房间")
+		return
+	}
+	fmt.Println("----- 房间详情 -----")
+	printRoomDetail(*room)
+	category := normalizeRoomType(room.Type)
+	if avg, samples := historicalAvgPrice(bookings, category); samples >= minAvgPriceSamples {
+		fmt.Printf("    历史均价: %.2f%s（基于%s %d 笔成交记录，仅供参考）\n", avg, priceUnitSuffix(*room), category, samples)
+	} else {
+		fmt.Println("    历史均价: 暂无历史数据")
+	}
+	booked := recentBookedQuantity(bookings, room.ID, time.Now(), heatWindow)
+	fmt.Println("    近期热度: " + roomHeatLabel(booked, room.Available, hotBookedThreshold(), lowStockThreshold()))
+	printRoomReviews(room.ID)
+}
+
+// ------------------------- 近期热度 ----------------------------
+
+// heatWindow 为统计近期热度的时间范围
+const heatWindow = 24 * time.Hour
+
+// 热度提醒阈值的默认值，管理员未设置时使用
+const (
+	defaultHotBookedThreshold = 3 // 近 24 小时订出不少于该间数时标记为热门
+	defaultLowStockThreshold  = 2 // 剩余不多于该间数时提示手慢无
+)
+
+// hotBookedThreshold 返回当前生效的热门阈值
+func hotBookedThreshold() int {
+	if settings.HotBookedThreshold > 0 {
+		return settings.HotBookedThreshold
+	}
+	return defaultHotBookedThreshold
+}
+
+// lowStockThreshold 返回当前生效的剩余紧张阈值
+func lowStockThreshold() int {
+	if settings.LowStockThreshold > 0 {
+		return settings.LowStockThreshold
+	}
+	return defaultLowStockThreshold
+}
+
+// heatThresholdLabel 返回热度提醒阈值的显示文字
+func heatThresholdLabel() string {
+	return fmt.Sprintf("24 小时订出 %d 间及以上为热门，剩余 %d 间及以下提示紧张", hotBookedThreshold(), lowStockThreshold())
+}
+
+// recentBookedQuantity 统计 list 中下单时间在 now 之前 window 以内、未取消的订单订走该房间的间数；
+// 下单时间按 now 所在时区解析，无法解析的旧订单不计入
+func recentBookedQuantity(list []Booking, roomID int, now time.Time, window time.Duration) int {
+	count := 0
+	for _, booking := range list {
+		if booking.Status == bookingStatusCancelled {
+			continue
+		}
+		created, err := time.ParseInLocation(timeLayout, booking.CreatedAt, now.Location())
+		if err != nil || created.After(now) || now.Sub(created) > window {
+			continue
+		}
+		count += bookingRoomQuantity(booking, roomID)
+	}
+	return count
+}
+
+// roomHeatLabel 根据近 24 小时订出的间数 booked 和当前剩余 remaining 生成热度说明：
+// 有订出时显示订出间数，达到 hot 间时标注热门；剩余 1 到 low 间时提示手慢无，已订满时提示已订满；
+// 没有近期订单且库存充足时显示“近期预订平稳”
+func roomHeatLabel(booked, remaining, hot, low int) string {
+	var parts []string
+	if booked > 0 {
+		part := fmt.Sprintf("最近24小时被订走 %d 间", booked)
+		if booked >= hot {
+			part += "（热门）"
+		}
+		parts = append(parts, part)
+	}
+	if remaining <= 0 {
+		parts = append(parts, "已订满")
+	} else if remaining <= low {
+		parts = append(parts, fmt.Sprintf("仅剩 %d 间，手慢无", remaining))
+	}
+	if len(parts) == 0 {
+		return "近期预订平稳"
+	}
+	return strings.Join(parts, "，")
+}
+
+// minAvgPriceSamples 为展示历史均价所需的最少成交记录数，记录太少时均价没有参考意义
+const minAvgPriceSamples = 3
+
+// historicalAvgPrice 计算某标准房型在 list 中未取消订单的成交均价，按成交单价（UnitPrice）以间数加权，
+// 不使用房间当前的挂牌价；缺少成交单价的旧订单不参与计算。samples 为参与计算的成交记录数
+func historicalAvgPrice(list []Booking, category string) (avg float64, samples int) {
+	sum, count := 0.0, 0
+	for _, booking := range list {
+		if booking.Status == bookingStatusCancelled {
+			continue
+		}
+		for _, item := range bookingItems(booking) {
+			if item.UnitPrice <= 0 || item.Quantity <= 0 || roomCategoryOf(item.RoomID) != category {
+				continue
+			}
+			sum += item.UnitPrice * float64(item.Quantity)
+			count += item.Quantity
+			samples++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return sum / float64(count), samples
+}
+
+// closeMyAccount 顾客注销自己的账户：需无未取消预订并验证密码，二次确认后软删除账户；
+// 返回 true 表示账户已注销，调用方应立即登出
+func closeMyAccount(customer *User) bool {
+	if active := activeBookingsForUser(customer.ID); len(active) > 0 {
+		fmt.Printf("您还有 %d 个未完成的预订，请先取消或办理退房后再注销账户：\n", len(active))
+		printBookingPointers(active)
+		return false
+	}
+	fmt.Print("请输入密码确认身份：")
+	if !checkPassword(customer.Password, readPassword()) {
+		fmt.Println("密码错误")
+		return false
+	}
+	if customer.Balance > 0 {
+		fmt.Printf("注意：账户余额 %.2f 元注销后无法退还，如需退款请先联系前台办理。\n", customer.Balance)
+	}
+	fmt.Print("注销后账户将无法再登录，且不可恢复，确定要注销吗？(y/n): ")
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		fmt.Println("已取消注销")
+		return false
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	customer.Deleted = true
+	saveUsers()
+	logOperation(operatorName(), "注销账户", fmt.Sprintf("成功，剩余余额 %.2f", customer.Balance))
+	fmt.Println("账户已注销，感谢您的使用")
+	return true
+}
+
+// findUserBooking 按订单号查找属于指定用户的订单，找不到时返回 nil
+func findUserBooking(userID int, orderNo string) *Booking {
+	for i := range bookings {
+		if bookingNo(bookings[i]) == orderNo && bookings[i].UserID == userID {
+			return &bookings[i]
+		}
+	}
+	return nil
+}
+
+// modifyMyBooking 顾客修改自己未取消预订的房间数量，按差额补款或退款。
+// 等待输入时不持有 dataMu，输入完成后加锁重新查找订单，由 changeBookingQuantity 重新校验
+func modifyMyBooking(customer *User) {
+	fmt.Print("请输入要修改的订单号：")
+	orderNo := readLine()
+	booking := findUserBooking(customer.ID, orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if booking.Status != bookingStatusBooked {
+		fmt.Printf("该订单%s，无法修改\n", bookingStatusLabel(booking.Status))
+		return
+	}
+	fmt.Printf("订单号: %s, 房间: %s, 当前数量: %d, 金额: %.2f\n",
+		bookingNo(*booking), bookingRoomsLabel(*booking), booking.Quantity, booking.TotalCost)
+	fmt.Print("请输入新的数量：")
+	quantity, err := strconv.Atoi(readLine())
+	if err != nil {
+		fmt.Println("无效的数量")
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	// 输入期间订单可能已被取消，且 bookings 追加后原指针可能失效，需重新查找
+	booking = findUserBooking(customer.ID, orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	delta, err := changeBookingQuantity(customer, booking, quantity)
+	logOperation(operatorName(), fmt.Sprintf("修改订单 %s 数量为 %d", orderNo, quantity), resultOf(err))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	saveUsers()
+	saveRooms()
+	saveBookings()
+	if delta > 0 {
+		fmt.Printf("修改成功！补款 %.2f 元，当前余额: %.2f\n", delta, customer.Balance)
+	} else {
+		fmt.Printf("修改成功！退款 %.2f 元，当前余额: %.2f\n", -delta, customer.Balance)
+	}
+}
+
+// changeBookingQuantity 把未取消预订的数量改为 quantity，按原订单每间的实付金额计算差额：
+// 增加数量时校验库存、持有上限和余额并补扣款，减少数量时退还差额并释放库存；
+// 同步调整积分和流水，返回余额变动金额（正数为补款）。调用方需持有 dataMu 并负责保存
+func changeBookingQuantity(customer *User, booking *Booking, quantity int) (float64, error) {
+	if booking.Status != bookingStatusBooked {
+		return 0, fmt.Errorf("该订单%s，无法修改", bookingStatusLabel(booking.Status))
+	}
+	if quantity <= 0 {
+		return 0, errors.New("数量必须大于 0，如需退订请使用取消预订")
+	}
+	if len(booking.Items) > 0 {
+		return 0, errors.New("多房间订单暂不支持修改数量，请取消后重新下单")
+	}
+	if booking.CouponCode != "" {
+		return 0, errors.New("使用了优惠券的订单暂不支持修改数量，请取消后重新下单")
+	}
+	diff := quantity - booking.Quantity
+	if diff == 0 {
+		return 0, errors.New("数量未变化")
+	}
+	room := findRoomByID(booking.RoomID)
+	if room == nil {
+		return 0, errors.New("该房间已被删除，无法修改")
+	}
+	perRoom := booking.TotalCost / float64(booking.Quantity)
+	delta := perRoom * float64(diff)
+	if diff > 0 {
+		if quantity > maxRoomsPerBooking {
+			return 0, fmt.Errorf("单次预订最多 %d 间", maxRoomsPerBooking)
+		}
+		if err := checkBookingLimits(customer.ID, room.ID, diff); err != nil {
+			return 0, err
+		}
+		if diff > room.Available || diff > availableRoomsOn(room.ID, booking.CheckIn, booking.CheckOut) {
+			return 0, errors.New("剩余房间不足，无法增加数量")
+		}
+		if customer.Balance < delta {
+			return 0, fmt.Errorf("余额不足，需补款 %.2f 元，当前余额 %.2f", delta, customer.Balance)
+		}
+	}
+	if err := adjustBalance(customer, -delta); err != nil {
+		return 0, err
+	}
+	room.Available -= diff
+	if room.Available > room.Total {
+		room.Available = room.Total
+	}
+	// 周末、节假日加价按每间相同，随数量等比调整，收据中的原价与实付保持一致
+	booking.Surcharge = math.Round(booking.Surcharge/float64(booking.Quantity)*float64(quantity)*100) / 100
+	booking.Quantity = quantity
+	booking.TotalCost += delta
+	// 数量变化后重新分配房间号，优先保留原有的房间号
+	booking.UnitNumbers = assignUnits(bookings, *booking)
+	points := pointsForAmount(booking.TotalCost)
+	adjustPoints(customer, points-booking.Points)
+	booking.Points = points
+	if delta > 0 {
+		recordTransaction(customer.ID, -delta, transactionTypeBooking, fmt.Sprintf("订单 %s 增加数量补款", bookingNo(*booking)))
+	} else {
+		recordTransaction(customer.ID, -delta, transactionTypeRefund, fmt.Sprintf("订单 %s 减少数量退款", bookingNo(*booking)))
+	}
+	return delta, nil
+}
+
+// cancelMyBooking 顾客取消自己名下未取消的预订，按退款策略退款并释放库存。
+// 等待确认时不持有 dataMu，确认后加锁重新查找并校验订单
+func cancelMyBooking(customer *User) {
+	fmt.Print("请输入要取消的订单号：")
+	orderNo := readLine()
+	booking := findUserBooking(customer.ID, orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if err := checkBookingTransition(booking.Status, bookingStatusCancelled); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("订单号: %s, 房间: %s, 数量: %d, 金额: %.2f\n",
+		bookingNo(*booking), bookingRoomsLabel(*booking), booking.Quantity, booking.TotalCost)
+	shown := *booking
+	refund := refundAmount(shown, time.Now())
+	fmt.Printf("退款策略：%s\n", refundPolicyLabel(refundPolicy()))
+	fmt.Printf("现在取消可退 %.2f 元，手续费 %.2f 元\n", refund, booking.TotalCost-refund)
+	fmt.Print("确定要取消该预订吗？(y/n): ")
+	confirm := readLine()
+	if confirm != "y" && confirm != "Y" {
+		return
+	}
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	booking = findUserBooking(customer.ID, orderNo)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	if err := checkBookingTransition(booking.Status, bookingStatusCancelled); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// 确认期间订单的数量或金额被修改过时，之前显示的退款金额已不准确
+	if booking.Quantity != shown.Quantity || booking.TotalCost != shown.TotalCost {
+		fmt.Println("订单在确认期间已被修改，请重新操作")
+		return
+	}
+	booking.CancelledBy = cancelledByCustomer
+	cancelBookingWithRefund(booking, refund)
+	saveUsers()
+	saveRooms()
+	saveBookings()
+	fmt.Printf("预订已取消，退款 %.2f 元，当前余额: %.2f\n", refund, customer.Balance)
+	if booking.Points > 0 {
+		fmt.Printf("已扣回积分 %d，当前积分: %d\n", booking.Points, customer.Points)
+	}
+}
+
+// listMyBookings 按下单时间倒序列出当前顾客的所有预订（含已取消的订单）
+func listMyBookings(customer *User) {
+	var mine []Booking
+	for _, booking := range bookings {
+		if booking.UserID == customer.ID {
+			mine = append(mine, booking)
+		}
+	}
+	if len(mine) == 0 {
+		fmt.Println("暂无预订记录")
+		return
+	}
+	sortBookingsNewestFirst(mine)
+	fmt.Println("----- 我的预订 -----")
+	for _, booking := range mine {
+		fmt.Printf("订单号: %s, 房间: %s, 数量: %d, 金额: %.2f, 入住: %s, 退房: %s, 下单时间: %s, 状态: %s\n",
+			bookingNo(booking), bookingRoomsLabel(booking), booking.Quantity, booking.TotalCost,
+			booking.CheckIn, booking.CheckOut, booking.CreatedAt, bookingStatusText(booking))
+		printBookingNote(booking.Note)
+	}
+	fmt.Print("输入订单号可生成收据（直接回车跳过）：")
+	input := readLine()
+	if input == "" {
+		return
+	}
+	booking := findUserBooking(customer.ID, input)
+	if booking == nil {
+		fmt.Println("未找到该订单")
+		return
+	}
+	saveReceipt(*booking)
+}
+
+// sortBookingsNewestFirst 按下单时间倒序排列预订，时间相同时订单号大的在前
+func sortBookingsNewestFirst(list []Booking) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].CreatedAt != list[j].CreatedAt {
+			return list[i].CreatedAt > list[j].CreatedAt
+		}
+		return list[i].ID > list[j].ID
+	})
+}
+
+// roomTypeName 返回房间类型名称，房间已被删除时返回 "(已删除)"
+func roomTypeName(roomID int) string {
+	room := findRoomByID(roomID)
+	if room == nil {
+		return "(已删除)"
+	}
+	return room.Type
+}
+
+// bookingStatusLabel 返回预订状态的中文说明
+func bookingStatusLabel(status string) string {
+	switch status {
+	case bookingStatusBooked:
+		return "已预订"
+	case bookingStatusCheckedIn:
+		return "已入住"
+	case bookingStatusCheckedOut:
+		return "已退房"
+	case bookingStatusCancelled:
+		return "已取消"
+	default:
+		return status
+	}
+}
+
+// bookingStatusText 返回订单的状态说明，已取消的订单附带取消来源，管理员取消时还附带原因
+func bookingStatusText(booking Booking) string {
+	label := bookingStatusLabel(booking.Status)
+	if booking.Status != bookingStatusCancelled {
+		return label
+	}
+	switch booking.CancelledBy {
+	case cancelledByAdmin:
+		if booking.CancelReason != "" {
+			return fmt.Sprintf("%s（管理员取消：%s）", label, booking.CancelReason)
+		}
+		return label + "（管理员取消）"
+	case cancelledByCustomer:
+		return label + "（顾客取消）"
+	default:
+		return label
+	}
+}